@@ -17,6 +17,16 @@ limitations under the License.
 package controller
 
 import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
 	"api-service/models"
 	backendmodels "envhub/models"
 
@@ -24,12 +34,63 @@ import (
 	"api-service/util"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
 	log "github.com/sirupsen/logrus"
 )
 
+// execUpgrader upgrades GET /env-instance/:id/exec to a WebSocket, the same
+// transport EnvInstanceClient.Exec dials as a client.
+var execUpgrader = websocket.Upgrader{
+	ReadBufferSize:  4096,
+	WriteBufferSize: 4096,
+	// The dashboard and api-service are not always same-origin; auth is
+	// handled by AuthTokenMiddleware ahead of this handler, not by origin
+	// checks here.
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// requestIDHeader is the incoming header ctxWithRequestID reads for
+// correlation; when a request carries none, ctxWithRequestID generates one
+// instead of leaving it blank.
+const requestIDHeader = "X-Request-ID"
+
+// ctxWithRequestID builds the context threaded into EnvInstanceService calls,
+// carrying the request's X-Request-ID (or a freshly generated one) so
+// EnvInstanceClient can echo it on the outgoing HTTP request for
+// cross-service correlation.
+func ctxWithRequestID(c *gin.Context) context.Context {
+	id := c.GetHeader(requestIDHeader)
+	if id == "" {
+		id = newRequestID()
+	}
+	return service.WithRequestID(c.Request.Context(), id)
+}
+
+// newRequestID generates a short random id for requests that arrive without
+// one already set.
+func newRequestID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(buf)
+}
+
+// jsonErrorForBackend maps a backend call failure to a JSON error response,
+// surfacing 503 instead of 500 when the failure is a tripped circuit
+// breaker rather than an unexpected error.
+func jsonErrorForBackend(c *gin.Context, message string, err error) {
+	status := 500
+	if errors.Is(err, service.ErrBackendUnavailable) {
+		status = 503
+	}
+	backendmodels.JSONErrorWithMessage(c, status, message+err.Error())
+}
+
 // EnvInstanceController handles EnvInstance operations
 type EnvInstanceController struct {
 	envInstanceService service.EnvInstanceService // use interface
+	warmPool           *service.WarmPoolManager
 	backendClient      *service.BackendClient
 	redisClient        *service.RedisClient
 }
@@ -37,11 +98,13 @@ type EnvInstanceController struct {
 // NewEnvInstanceController creates a new EnvInstance controller instance
 func NewEnvInstanceController(
 	envInstanceService service.EnvInstanceService,
+	warmPool *service.WarmPoolManager,
 	backendClient *service.BackendClient,
 	redisClient *service.RedisClient,
 ) *EnvInstanceController {
 	return &EnvInstanceController{
 		envInstanceService: envInstanceService,
+		warmPool:           warmPool,
 		backendClient:      backendClient,
 		redisClient:        redisClient,
 	}
@@ -102,10 +165,28 @@ func (ctrl *EnvInstanceController) CreateEnvInstance(c *gin.Context) {
 	}
 	// Set TTL for environment
 	backendEnv.DeployConfig["ttl"] = req.TTL
-	// Call ScheduleClient to create Pod
-	envInstance, err := ctrl.envInstanceService.CreateEnvInstance(backendEnv)
+
+	// Hand out a pre-warmed instance if the pool has one ready, otherwise
+	// fall back to a synchronous create through the backend. A pre-warmed
+	// instance was created from whatever template was tracked earlier, so
+	// it can't carry this request's datasource/env-var/argument overrides -
+	// requests that set any of those always go through a synchronous
+	// create instead of a pool hit.
+	hasCustomization := req.Datasource != "" || req.EnvironmentVariables != nil || req.Arguments != nil
+
+	var envInstance *models.EnvInstance
+	if ctrl.warmPool != nil {
+		ctrl.warmPool.Track(backendEnv)
+		if !hasCustomization {
+			envInstance, err = ctrl.warmPool.Acquire(name)
+		} else {
+			envInstance, err = ctrl.envInstanceService.CreateEnvInstance(ctxWithRequestID(c), backendEnv)
+		}
+	} else {
+		envInstance, err = ctrl.envInstanceService.CreateEnvInstance(ctxWithRequestID(c), backendEnv)
+	}
 	if err != nil {
-		backendmodels.JSONErrorWithMessage(c, 500, "Failed to create: "+err.Error())
+		jsonErrorForBackend(c, "Failed to create: ", err)
 		return
 	}
 	envInstance.Env = backendEnv
@@ -129,9 +210,9 @@ func (ctrl *EnvInstanceController) GetEnvInstance(c *gin.Context) {
 		return
 	}
 	// Call ScheduleClient to query Pod
-	envInstance, err := ctrl.envInstanceService.GetEnvInstance(id)
+	envInstance, err := ctrl.envInstanceService.GetEnvInstance(ctxWithRequestID(c), id)
 	if err != nil {
-		backendmodels.JSONErrorWithMessage(c, 500, "Failed to query: "+err.Error())
+		jsonErrorForBackend(c, "Failed to query: ", err)
 		return
 	}
 	backendmodels.JSONSuccess(c, envInstance)
@@ -147,11 +228,14 @@ func (ctrl *EnvInstanceController) DeleteEnvInstance(c *gin.Context) {
 	}
 
 	// Call ScheduleClient to delete Pod
-	err := ctrl.envInstanceService.DeleteEnvInstance(id)
+	err := ctrl.envInstanceService.DeleteEnvInstance(ctxWithRequestID(c), id)
 	if err != nil {
-		backendmodels.JSONErrorWithMessage(c, 500, "Failed to delete: "+err.Error())
+		jsonErrorForBackend(c, "Failed to delete: ", err)
 		return
 	}
+	if ctrl.warmPool != nil {
+		ctrl.warmPool.Release(id)
+	}
 	backendmodels.JSONSuccess(c, "Deleted successfully")
 	token := util.GetCurrentToken(c)
 	if token != nil && ctrl.redisClient != nil {
@@ -183,9 +267,9 @@ func (ctrl *EnvInstanceController) ListEnvInstances(c *gin.Context) {
 		log.Warnf("failed to list from redis: %v", err)
 	}
 	envName := c.Query("envName")
-	instances, err := ctrl.envInstanceService.ListEnvInstances(envName)
+	instances, err := ctrl.envInstanceService.ListEnvInstances(ctxWithRequestID(c), envName)
 	if err != nil {
-		backendmodels.JSONErrorWithMessage(c, 500, err.Error())
+		jsonErrorForBackend(c, "", err)
 		return
 	}
 	backendmodels.JSONSuccess(c, instances)
@@ -212,10 +296,180 @@ func (ctrl *EnvInstanceController) Warmup(c *gin.Context) {
 		return
 	}
 
-	err = ctrl.envInstanceService.Warmup(backendEnv)
-	if err != nil {
-		backendmodels.JSONErrorWithMessage(c, 500, err.Error())
+	// Warmup used to fire one synchronous create; now it's a thin wrapper
+	// that raises the pool's MinReady for this env so the reconciler keeps
+	// an extra instance on standby.
+	if ctrl.warmPool != nil {
+		ctrl.warmPool.Track(backendEnv)
+		if err := ctrl.warmPool.BumpMinReady(name, 1); err != nil {
+			backendmodels.JSONErrorWithMessage(c, 500, err.Error())
+			return
+		}
+	} else if err := ctrl.envInstanceService.Warmup(ctxWithRequestID(c), backendEnv); err != nil {
+		jsonErrorForBackend(c, "", err)
 		return
 	}
 	backendmodels.JSONSuccess(c, backendEnv)
 }
+
+// BatchDeleteRequest is the body of POST /env-instance/batch-delete.
+type BatchDeleteRequest struct {
+	IDs         []string `json:"ids" binding:"required"`
+	Concurrency int      `json:"concurrency"`
+}
+
+// BatchDeleteResponse reports which ids deleted successfully and why the
+// rest failed, rather than failing the whole request on the first error.
+type BatchDeleteResponse struct {
+	Succeeded []string          `json:"succeeded"`
+	Failed    map[string]string `json:"failed"`
+}
+
+// BatchDeleteEnvInstances tears down many EnvInstances concurrently.
+// POST /env-instance/batch-delete
+func (ctrl *EnvInstanceController) BatchDeleteEnvInstances(c *gin.Context) {
+	var req BatchDeleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		backendmodels.JSONErrorWithMessage(c, 400, "Invalid request parameters: "+err.Error())
+		return
+	}
+	if len(req.IDs) == 0 {
+		backendmodels.JSONErrorWithMessage(c, 400, "ids is required")
+		return
+	}
+
+	results, err := ctrl.envInstanceService.BatchDeleteEnvInstances(c.Request.Context(), req.IDs, req.Concurrency)
+	if err != nil {
+		backendmodels.JSONErrorWithMessage(c, 500, "Batch delete aborted: "+err.Error())
+		return
+	}
+
+	resp := BatchDeleteResponse{Failed: make(map[string]string)}
+	for id, delErr := range results {
+		if delErr != nil {
+			resp.Failed[id] = delErr.Error()
+			continue
+		}
+		resp.Succeeded = append(resp.Succeeded, id)
+		if ctrl.warmPool != nil {
+			ctrl.warmPool.Release(id)
+		}
+	}
+
+	token := util.GetCurrentToken(c)
+	if token != nil && ctrl.redisClient != nil {
+		succeeded := resp.Succeeded
+		if poolErr := service.RunBounded(c.Request.Context(), len(succeeded), req.Concurrency, func(i int) {
+			if result, err := ctrl.redisClient.RemoveEnvInstanceFromRedis(token.Token, succeeded[i]); !result || err != nil {
+				log.Warnf("failed to remove EnvInstance %s from Redis: %v", succeeded[i], err)
+			}
+		}); poolErr != nil {
+			log.Warnf("batch delete: redis cleanup pool stopped early: %v", poolErr)
+		}
+	}
+
+	backendmodels.JSONSuccess(c, resp)
+}
+
+// StreamLogs streams an EnvInstance's logs as SSE.
+// GET /env-instance/:id/logs?follow=true&lines=N&since=...
+func (ctrl *EnvInstanceController) StreamLogs(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		backendmodels.JSONErrorWithMessage(c, 400, "Missing id parameter")
+		return
+	}
+
+	opts := service.LogOptions{Follow: c.Query("follow") == "true"}
+	if lines := c.Query("lines"); lines != "" {
+		if n, err := strconv.Atoi(lines); err == nil {
+			opts.Tail = n
+		}
+	}
+	if since := c.Query("since"); since != "" {
+		if t, err := time.Parse(time.RFC3339, since); err == nil {
+			opts.Since = t
+		}
+	}
+
+	logs, err := ctrl.envInstanceService.StreamLogs(c.Request.Context(), id, opts)
+	if err != nil {
+		backendmodels.JSONErrorWithMessage(c, 500, "Failed to stream logs: "+err.Error())
+		return
+	}
+	defer logs.Close()
+
+	reader := bufio.NewReader(logs)
+	c.Stream(func(w io.Writer) bool {
+		line, err := reader.ReadString('\n')
+		if len(line) > 0 {
+			c.SSEvent("log", line)
+		}
+		// A dropped client connection cancels c.Request.Context(), which
+		// StreamLogs propagates down to the read that unblocks here.
+		return err == nil
+	})
+}
+
+// Exec upgrades to a WebSocket and runs a command inside an EnvInstance.
+// GET /env-instance/:id/exec
+func (ctrl *EnvInstanceController) Exec(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		backendmodels.JSONErrorWithMessage(c, 400, "Missing id parameter")
+		return
+	}
+
+	conn, err := execUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Warnf("exec %s: failed to upgrade to websocket: %v", id, err)
+		return
+	}
+	defer conn.Close()
+
+	var cmdFrame struct {
+		Cmd []string `json:"cmd"`
+	}
+	if err := conn.ReadJSON(&cmdFrame); err != nil {
+		log.Warnf("exec %s: failed to read command frame: %v", id, err)
+		return
+	}
+
+	stdinReader, stdinWriter := io.Pipe()
+	go func() {
+		defer stdinWriter.Close()
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if _, err := stdinWriter.Write(data); err != nil {
+				return
+			}
+		}
+	}()
+
+	stream, err := ctrl.envInstanceService.Exec(c.Request.Context(), id, cmdFrame.Cmd, stdinReader)
+	if err != nil {
+		log.Warnf("exec %s: failed to start: %v", id, err)
+		_ = conn.WriteJSON(map[string]string{"error": err.Error()})
+		return
+	}
+	defer stream.Close()
+
+	buf := make([]byte, 4096)
+	for {
+		n, err := stream.Read(buf)
+		if n > 0 {
+			if writeErr := conn.WriteMessage(websocket.BinaryMessage, buf[:n]); writeErr != nil {
+				return
+			}
+		}
+		if err != nil {
+			if err != io.EOF {
+				_ = conn.WriteJSON(map[string]string{"error": err.Error()})
+			}
+			return
+		}
+	}
+}
@@ -33,25 +33,34 @@ import (
 )
 
 var (
-	scheduleAddr  string
-	scheduleType  string
-	backendAddr   string
-	redisAddr     string
-	redisPassword string
-	qps           int64
+	scheduleAddr       string
+	scheduleType       string
+	scheduleNamespace  string
+	scheduleKubeconfig string
+	backendAddr        string
+	redisAddr          string
+	redisPassword      string
+	qps                int64
+	scheduleQPS        float64
+	scheduleBurst      int
 	// New: token cache configuration
 	tokenEnabled         bool
 	tokenCacheMaxEntries int
 	tokenCacheTTLMinutes int
 	cleanupInterval      string
+	warmPoolInterval     string
 )
 
 func init() {
 	pflag.StringVar(&scheduleAddr, "schedule-addr", "", "Meta service address (host:port)")
-	pflag.StringVar(&scheduleType, "schedule-type", "k8s", "sandbox service schedule type, currently only 'k8s', 'standard' support")
+	pflag.StringVar(&scheduleType, "schedule-type", "k8s", "sandbox backend to schedule env instances on: 'k8s' (schedule service), 'standard' (envhub instance API), 'kubernetes' (direct client-go), 'docker' (local dev)")
 	pflag.StringVar(&backendAddr, "backend-addr", "", "backend service address (host:port)")
+	pflag.StringVar(&scheduleNamespace, "schedule-namespace", "default", "namespace the 'kubernetes' backend creates pods in")
+	pflag.StringVar(&scheduleKubeconfig, "schedule-kubeconfig", "", "kubeconfig path for the 'kubernetes' backend; empty uses in-cluster config")
 
 	pflag.Int64Var(&qps, "qps", int64(100), "total qps limit")
+	pflag.Float64Var(&scheduleQPS, "schedule-qps", 20, "QPS for calls to the schedule (aenvhub) service")
+	pflag.IntVar(&scheduleBurst, "schedule-burst", 40, "Burst for calls to the schedule (aenvhub) service")
 	pflag.BoolVar(&tokenEnabled, "token-enabled", false, "token validate enabled")
 	pflag.IntVar(&tokenCacheMaxEntries, "token-cache-max-entries", 1000, "Maximum number of token cache entries (default 1000)")
 	pflag.IntVar(&tokenCacheTTLMinutes, "token-cache-ttl-minutes", 1, "Token cache TTL in minutes (default 1)")
@@ -59,6 +68,7 @@ func init() {
 	pflag.StringVar(&redisAddr, "redis-addr", "", "Redis address (host:port)")
 	pflag.StringVar(&redisPassword, "redis-password", "", "Redis password")
 	pflag.StringVar(&cleanupInterval, "cleanup-interval", "5m", "Cleanup service interval (e.g., 5m, 1h)")
+	pflag.StringVar(&warmPoolInterval, "warm-pool-interval", "30s", "Warm pool reconciler interval (e.g., 30s, 1m)")
 }
 
 func healthChecker(c *gin.Context) {
@@ -95,16 +105,26 @@ func main() {
 		log.Fatalf("Failed to create backend client: %v", err)
 	}
 
-	var scheduleClient service.EnvInstanceService
-	if scheduleType == "k8s" {
-		scheduleClient = service.NewScheduleClient(scheduleAddr)
-	} else if scheduleType == "standard" {
-		scheduleClient = service.NewEnvInstanceClient(scheduleAddr)
-	} else {
-		log.Fatalf("unsupported schedule type: %v", scheduleType)
+	scheduleClient, err := service.NewBackend(scheduleType, map[string]interface{}{
+		"baseURL":    scheduleAddr,
+		"qps":        scheduleQPS,
+		"burst":      scheduleBurst,
+		"namespace":  scheduleNamespace,
+		"kubeconfig": scheduleKubeconfig,
+	})
+	if err != nil {
+		log.Fatalf("failed to construct schedule backend: %v", err)
+	}
+
+	warmPoolReconcileInterval, err := time.ParseDuration(warmPoolInterval)
+	if err != nil {
+		log.Fatalf("Invalid warm pool interval: %v", err)
 	}
+	warmPoolManager := service.NewWarmPoolManager(scheduleClient, warmPoolReconcileInterval)
+	warmPoolManager.Start()
+	defer warmPoolManager.Stop()
 
-	envInstanceController := controller.NewEnvInstanceController(scheduleClient, backendClient, redisClient)
+	envInstanceController := controller.NewEnvInstanceController(scheduleClient, warmPoolManager, backendClient, redisClient)
 	// Main route configuration
 	mainRouter.POST("/env-instance",
 		middleware.AuthTokenMiddleware(tokenEnabled, backendClient),
@@ -114,6 +134,9 @@ func main() {
 	mainRouter.GET("/env-instance/:id/list", middleware.AuthTokenMiddleware(tokenEnabled, backendClient), envInstanceController.ListEnvInstances)
 	mainRouter.GET("/env-instance/:id", middleware.AuthTokenMiddleware(tokenEnabled, backendClient), envInstanceController.GetEnvInstance)
 	mainRouter.DELETE("/env-instance/:id", middleware.AuthTokenMiddleware(tokenEnabled, backendClient), envInstanceController.DeleteEnvInstance)
+	mainRouter.POST("/env-instance/batch-delete", middleware.AuthTokenMiddleware(tokenEnabled, backendClient), envInstanceController.BatchDeleteEnvInstances)
+	mainRouter.GET("/env-instance/:id/logs", middleware.AuthTokenMiddleware(tokenEnabled, backendClient), envInstanceController.StreamLogs)
+	mainRouter.GET("/env-instance/:id/exec", middleware.AuthTokenMiddleware(tokenEnabled, backendClient), envInstanceController.Exec)
 	mainRouter.GET("/health", healthChecker)
 	mainRouter.GET("/metrics", gin.WrapH(promhttp.Handler()))
 
@@ -144,7 +167,10 @@ func main() {
 	if err != nil {
 		log.Fatalf("Invalid cleanup interval: %v", err)
 	}
-	cleanManager := service.NewAEnvCleanManager(service.NewKubeCleaner(scheduleClient), interval)
+	// Cleanup is now pool-scoped (drains only idle-expired warm instances)
+	// instead of a service-wide reset, so it runs through warmPoolManager
+	// rather than service.NewKubeCleaner(scheduleClient).
+	cleanManager := service.NewAEnvCleanManager(warmPoolManager, interval)
 	go cleanManager.Start()
 	defer cleanManager.Stop()
 
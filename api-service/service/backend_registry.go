@@ -0,0 +1,62 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"fmt"
+	"sync"
+)
+
+// BackendFactory builds an EnvInstanceService from a backend-specific config
+// map, e.g. {"baseURL": ..., "qps": ...} for the HTTP-based backends or
+// {"kubeconfig": ..., "namespace": ...} for the Kubernetes backend.
+type BackendFactory func(cfg map[string]interface{}) (EnvInstanceService, error)
+
+var (
+	backendRegistryMu sync.Mutex
+	backendRegistry   = map[string]BackendFactory{}
+)
+
+// RegisterBackend makes an EnvInstanceService implementation available under
+// name for NewBackend to construct. Backends register themselves from an
+// init() in their own file (see schedule_client.go, env_instance.go,
+// kubernetes_backend.go, docker_backend.go), so adding a new sandbox runtime
+// never requires touching main.go's wiring beyond picking its name.
+// Registering the same name twice is a programming error and panics at
+// init time, the same way prometheus.MustRegister does for duplicate
+// collectors.
+func RegisterBackend(name string, factory BackendFactory) {
+	backendRegistryMu.Lock()
+	defer backendRegistryMu.Unlock()
+	if _, exists := backendRegistry[name]; exists {
+		panic(fmt.Sprintf("service: backend %q already registered", name))
+	}
+	backendRegistry[name] = factory
+}
+
+// NewBackend constructs the EnvInstanceService registered under name,
+// e.g. via --schedule-type. Returns an error rather than log.Fatalf so
+// callers (main.go, tests) can decide how to report an unknown backend.
+func NewBackend(name string, cfg map[string]interface{}) (EnvInstanceService, error) {
+	backendRegistryMu.Lock()
+	factory, ok := backendRegistry[name]
+	backendRegistryMu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("unsupported schedule type: %v", name)
+	}
+	return factory(cfg)
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"sync"
+
+	"api-service/models"
+	backend "envhub/models"
+)
+
+// RunBounded calls fn(i) for every i in [0,n) across at most concurrency
+// goroutines at once (a semaphore channel + WaitGroup). It is
+// exported so EnvInstanceController can run its own Redis bookkeeping
+// through the same pool shape a batch operation already used for the
+// backend calls themselves. Cancelling ctx stops dispatching new work and
+// returns ctx.Err() once everything already in flight has finished;
+// concurrency <= 0 is treated as 1.
+func RunBounded(ctx context.Context, n, concurrency int, fn func(i int)) error {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return ctx.Err()
+		case sem <- struct{}{}:
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+	return nil
+}
+
+// batchDeleteEnvInstances fans deleteOne out across ids through RunBounded,
+// aggregating each id's error rather than letting one failure abort the
+// rest of the batch. Shared by every EnvInstanceService implementation so
+// the worker-pool shape doesn't need reimplementing per backend.
+func batchDeleteEnvInstances(ctx context.Context, ids []string, concurrency int, deleteOne func(ctx context.Context, id string) error) (map[string]error, error) {
+	results := make(map[string]error, len(ids))
+	var mu sync.Mutex
+	err := RunBounded(ctx, len(ids), concurrency, func(i int) {
+		id := ids[i]
+		delErr := deleteOne(ctx, id)
+		mu.Lock()
+		results[id] = delErr
+		mu.Unlock()
+	})
+	return results, err
+}
+
+// batchCreateEnvInstances mirrors batchDeleteEnvInstances for creation.
+// Results are keyed by req.Name rather than a pre-create ID, since
+// backend.Env has no ID until createOne returns one; a batch with
+// duplicate names collapses to the last result for that name.
+func batchCreateEnvInstances(ctx context.Context, reqs []*backend.Env, concurrency int, createOne func(ctx context.Context, req *backend.Env) (*models.EnvInstance, error)) (map[string]*models.EnvInstance, map[string]error, error) {
+	created := make(map[string]*models.EnvInstance, len(reqs))
+	failed := make(map[string]error)
+	var mu sync.Mutex
+	err := RunBounded(ctx, len(reqs), concurrency, func(i int) {
+		req := reqs[i]
+		instance, createErr := createOne(ctx, req)
+		mu.Lock()
+		if createErr != nil {
+			failed[req.Name] = createErr
+		} else {
+			created[req.Name] = instance
+		}
+		mu.Unlock()
+	})
+	return created, failed, err
+}
@@ -87,5 +87,5 @@ func NewKubeCleaner(scheduleClient EnvInstanceService) *KubeCleaner {
 
 // cleanup executes cleanup task
 func (cs *KubeCleaner) cleanup() {
-	_ = cs.scheduleClient.Cleanup()
+	_ = cs.scheduleClient.Cleanup(context.Background())
 }
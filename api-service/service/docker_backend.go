@@ -0,0 +1,264 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"api-service/models"
+	backend "envhub/models"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+)
+
+const dockerEnvLabel = "aenv.io/env-name"
+
+func init() {
+	RegisterBackend("docker", newDockerBackendFromConfig)
+}
+
+// newDockerBackendFromConfig is the "docker" BackendFactory, for running
+// env instances as plain local containers - no cluster required, meant for
+// developing against api-service without either envhub or a Kubernetes
+// cluster on hand.
+func newDockerBackendFromConfig(cfg map[string]interface{}) (EnvInstanceService, error) {
+	host, _ := cfg["host"].(string)
+	return NewDockerBackend(host)
+}
+
+// DockerBackend is an EnvInstanceService backed by a local Docker daemon.
+type DockerBackend struct {
+	cli *client.Client
+}
+
+// NewDockerBackend connects to the Docker daemon at host. When host is
+// empty, the client's environment settings (DOCKER_HOST) apply, falling back
+// to client.DefaultDockerHost ("unix:///var/run/docker.sock" on Linux).
+func NewDockerBackend(host string) (*DockerBackend, error) {
+	opts := []client.Opt{client.FromEnv, client.WithAPIVersionNegotiation()}
+	if host != "" {
+		opts = append(opts, client.WithHost(host))
+	}
+	cli, err := client.NewClientWithOpts(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("docker backend: failed to connect to docker daemon: %v", err)
+	}
+	return &DockerBackend{cli: cli}, nil
+}
+
+// CreateEnvInstance runs req as a detached container named
+// "<env name>-<unix nanoseconds>" and labeled with dockerEnvLabel. Both
+// differ from podFromEnv's Pod naming (GenerateName) and its env label.
+func (b *DockerBackend) CreateEnvInstance(ctx context.Context, req *backend.Env) (*models.EnvInstance, error) {
+	image := req.Content.Image
+	if v, ok := req.DeployConfig["secondImageName"].(string); ok && v != "" {
+		image = v
+	}
+	if image == "" {
+		return nil, fmt.Errorf("docker backend: env %s has no image", req.Name)
+	}
+
+	var env []string
+	if envMap, ok := req.DeployConfig["environmentVariables"].(map[string]string); ok {
+		for k, v := range envMap {
+			env = append(env, fmt.Sprintf("%s=%s", k, v))
+		}
+	}
+	var cmd []string
+	if v, ok := req.DeployConfig["arguments"].([]string); ok {
+		cmd = v
+	}
+
+	containerName := fmt.Sprintf("%s-%d", req.Name, time.Now().UnixNano())
+	resp, err := b.cli.ContainerCreate(ctx, &container.Config{
+		Image:  image,
+		Env:    env,
+		Cmd:    cmd,
+		Labels: map[string]string{dockerEnvLabel: req.Name},
+	}, nil, nil, nil, containerName)
+	if err != nil {
+		return nil, fmt.Errorf("docker backend: failed to create container: %v", err)
+	}
+	if err := b.cli.ContainerStart(ctx, resp.ID, types.ContainerStartOptions{}); err != nil {
+		return nil, fmt.Errorf("docker backend: failed to start container %s: %v", resp.ID, err)
+	}
+
+	return b.GetEnvInstance(ctx, resp.ID)
+}
+
+// GetEnvInstance inspects the container backing id.
+func (b *DockerBackend) GetEnvInstance(ctx context.Context, id string) (*models.EnvInstance, error) {
+	info, err := b.cli.ContainerInspect(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("docker backend: failed to inspect container %s: %v", id, err)
+	}
+	createdAt := info.Created
+	return &models.EnvInstance{
+		ID:        info.ID,
+		Name:      info.Config.Labels[dockerEnvLabel],
+		Status:    info.State.Status,
+		CreatedAt: createdAt,
+	}, nil
+}
+
+// DeleteEnvInstance force-removes the container backing id. A missing
+// container is treated as an already-completed delete, matching
+// KubernetesBackend.DeleteEnvInstance's idempotent-delete behavior.
+func (b *DockerBackend) DeleteEnvInstance(ctx context.Context, id string) error {
+	err := b.cli.ContainerRemove(ctx, id, types.ContainerRemoveOptions{Force: true})
+	if err != nil && !client.IsErrNotFound(err) {
+		return fmt.Errorf("docker backend: failed to remove container %s: %v", id, err)
+	}
+	return nil
+}
+
+// ListEnvInstances lists every container labeled with dockerEnvLabel,
+// optionally filtered down to a single env name.
+func (b *DockerBackend) ListEnvInstances(ctx context.Context, envName string) ([]*models.EnvInstance, error) {
+	filterArgs := filters.NewArgs()
+	if envName != "" {
+		filterArgs.Add("label", fmt.Sprintf("%s=%s", dockerEnvLabel, envName))
+	} else {
+		filterArgs.Add("label", dockerEnvLabel)
+	}
+
+	containers, err := b.cli.ContainerList(ctx, types.ContainerListOptions{All: true, Filters: filterArgs})
+	if err != nil {
+		return nil, fmt.Errorf("docker backend: failed to list containers: %v", err)
+	}
+	instances := make([]*models.EnvInstance, 0, len(containers))
+	for _, c := range containers {
+		instances = append(instances, &models.EnvInstance{
+			ID:     c.ID,
+			Name:   c.Labels[dockerEnvLabel],
+			Status: c.Status,
+		})
+	}
+	return instances, nil
+}
+
+// Warmup is a no-op for the same reason as KubernetesBackend.Warmup:
+// CreateEnvInstance already runs a real container synchronously.
+func (b *DockerBackend) Warmup(ctx context.Context, req *backend.Env) error {
+	return nil
+}
+
+// Cleanup removes every stopped container this backend created, since local
+// dev has no controller-module TTL reaper watching over it.
+func (b *DockerBackend) Cleanup(ctx context.Context) error {
+	filterArgs := filters.NewArgs()
+	filterArgs.Add("label", dockerEnvLabel)
+	filterArgs.Add("status", "exited")
+
+	containers, err := b.cli.ContainerList(ctx, types.ContainerListOptions{All: true, Filters: filterArgs})
+	if err != nil {
+		return fmt.Errorf("docker backend: failed to list exited containers: %v", err)
+	}
+	for _, c := range containers {
+		if err := b.cli.ContainerRemove(ctx, c.ID, types.ContainerRemoveOptions{Force: true}); err != nil {
+			return fmt.Errorf("docker backend: failed to remove exited container %s: %v", c.ID, err)
+		}
+	}
+	return nil
+}
+
+// StreamLogs streams the container's logs directly from the Docker daemon;
+// cancelling ctx aborts the underlying API call and closes the stream.
+func (b *DockerBackend) StreamLogs(ctx context.Context, id string, opts LogOptions) (io.ReadCloser, error) {
+	logOpts := types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     opts.Follow,
+	}
+	if opts.Tail > 0 {
+		logOpts.Tail = fmt.Sprintf("%d", opts.Tail)
+	}
+	if !opts.Since.IsZero() {
+		logOpts.Since = opts.Since.UTC().Format(time.RFC3339)
+	}
+
+	stream, err := b.cli.ContainerLogs(ctx, id, logOpts)
+	if err != nil {
+		return nil, fmt.Errorf("docker backend: failed to stream logs for container %s: %v", id, err)
+	}
+	return stream, nil
+}
+
+// Exec runs cmd inside the container via the Docker exec API, attaching
+// stdin/stdout/stderr over a single hijacked connection.
+func (b *DockerBackend) Exec(ctx context.Context, id string, cmd []string, stdin io.Reader) (ExecStream, error) {
+	created, err := b.cli.ContainerExecCreate(ctx, id, types.ExecConfig{
+		Cmd:          cmd,
+		AttachStdin:  stdin != nil,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("docker backend: failed to create exec for container %s: %v", id, err)
+	}
+
+	attached, err := b.cli.ContainerExecAttach(ctx, created.ID, types.ExecStartCheck{})
+	if err != nil {
+		return nil, fmt.Errorf("docker backend: failed to attach exec for container %s: %v", id, err)
+	}
+
+	if stdin != nil {
+		go func() {
+			io.Copy(attached.Conn, stdin)
+			attached.CloseWrite()
+		}()
+	}
+	go func() {
+		<-ctx.Done()
+		attached.Close()
+	}()
+
+	return &dockerExecStream{HijackedResponse: attached}, nil
+}
+
+// dockerExecStream adapts Docker's HijackedResponse (a bufio.Reader over a
+// net.Conn) to the ExecStream/io.ReadCloser contract.
+type dockerExecStream struct {
+	types.HijackedResponse
+}
+
+func (s *dockerExecStream) Read(p []byte) (int, error) {
+	return s.Reader.Read(p)
+}
+
+func (s *dockerExecStream) Close() error {
+	s.HijackedResponse.Close()
+	return nil
+}
+
+// BatchDeleteEnvInstances implements EnvInstanceService interface, fanning
+// out to DeleteEnvInstance through a bounded worker pool.
+func (b *DockerBackend) BatchDeleteEnvInstances(ctx context.Context, ids []string, concurrency int) (map[string]error, error) {
+	return batchDeleteEnvInstances(ctx, ids, concurrency, b.DeleteEnvInstance)
+}
+
+// BatchCreateEnvInstances implements EnvInstanceService interface, fanning
+// out to CreateEnvInstance through a bounded worker pool.
+func (b *DockerBackend) BatchCreateEnvInstances(ctx context.Context, reqs []*backend.Env, concurrency int) (map[string]*models.EnvInstance, map[string]error, error) {
+	return batchCreateEnvInstances(ctx, reqs, concurrency, b.CreateEnvInstance)
+}
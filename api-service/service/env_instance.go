@@ -3,75 +3,270 @@ package service
 import (
 	"api-service/models"
 	"bytes"
+	"context"
 	"encoding/json"
 	backend "envhub/models"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/url"
+	"strconv"
 	"time"
+
+	"github.com/gorilla/websocket"
 )
 
 const AEnvOpenAPIPrefix = "aenvironment/"
 const AEnvOpenAPIInstance = AEnvOpenAPIPrefix + "instance"
 
+// LogOptions configures StreamLogs. A zero value streams the instance's
+// current log buffer once and returns, matching `kubectl logs` without
+// -f/--tail/--since.
+type LogOptions struct {
+	Follow bool
+	Tail   int
+	Since  time.Time
+}
+
+// ExecStream is the combined stdout/stderr stream Exec returns; stdin is
+// supplied up front as Exec's stdin argument rather than through the
+// stream itself, so callers never need to guess which writes go where.
+type ExecStream interface {
+	io.ReadCloser
+}
+
 // EnvInstanceService defines sandbox crud interfaces
 type EnvInstanceService interface {
-	GetEnvInstance(id string) (*models.EnvInstance, error)
-	CreateEnvInstance(req *backend.Env) (*models.EnvInstance, error)
-	DeleteEnvInstance(id string) error
-	ListEnvInstances(envName string) ([]*models.EnvInstance, error)
-	Warmup(req *backend.Env) error
-	Cleanup() error
+	GetEnvInstance(ctx context.Context, id string) (*models.EnvInstance, error)
+	CreateEnvInstance(ctx context.Context, req *backend.Env) (*models.EnvInstance, error)
+	DeleteEnvInstance(ctx context.Context, id string) error
+	ListEnvInstances(ctx context.Context, envName string) ([]*models.EnvInstance, error)
+	Warmup(ctx context.Context, req *backend.Env) error
+	Cleanup(ctx context.Context) error
+	// StreamLogs returns the instance's logs; the caller must Close the
+	// returned ReadCloser, and cancelling ctx tears the underlying
+	// connection down so a follow=true stream stops immediately instead of
+	// leaking a goroutine/connection.
+	StreamLogs(ctx context.Context, id string, opts LogOptions) (io.ReadCloser, error)
+	// Exec runs cmd inside the instance, streaming stdin in and the
+	// combined stdout/stderr back through the returned ExecStream.
+	Exec(ctx context.Context, id string, cmd []string, stdin io.Reader) (ExecStream, error)
+	// BatchDeleteEnvInstances deletes every id through a worker pool bounded
+	// by concurrency, aggregating per-id errors instead of aborting the
+	// whole batch on the first failure.
+	BatchDeleteEnvInstances(ctx context.Context, ids []string, concurrency int) (map[string]error, error)
+	// BatchCreateEnvInstances mirrors BatchDeleteEnvInstances for creation,
+	// keyed by each request's Name.
+	BatchCreateEnvInstances(ctx context.Context, reqs []*backend.Env, concurrency int) (map[string]*models.EnvInstance, map[string]error, error)
+}
+
+// ClientOptions configures EnvInstanceClient's resilience behavior: how hard
+// it retries idempotent verbs, which statuses count as retryable, and when
+// its per-host circuit breaker trips.
+type ClientOptions struct {
+	MaxRetries       int
+	BackoffBase      time.Duration
+	BackoffMax       time.Duration
+	RetryableStatus  []int
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+	// Tracer, if set, is invoked once per attempt with the endpoint name and
+	// the X-Request-ID assigned to the call, before the request is sent.
+	Tracer func(endpoint, requestID string)
+}
+
+// DefaultClientOptions tries GET/DELETE/PUT up to 4 times in all with the same
+// backoff envelope ScheduleClient already uses (DefaultRetryPolicy), treats
+// 429/502/503/504 as retryable, and opens the breaker after 5 consecutive
+// failures for 30s.
+var DefaultClientOptions = ClientOptions{
+	MaxRetries:       DefaultRetryPolicy.MaxAttempts,
+	BackoffBase:      DefaultRetryPolicy.InitialDelay,
+	BackoffMax:       DefaultRetryPolicy.MaxDelay,
+	RetryableStatus:  []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+	BreakerThreshold: 5,
+	BreakerCooldown:  30 * time.Second,
 }
 
 type EnvInstanceClient struct {
 	baseURL    string
 	httpClient *http.Client
+	// streamClient has no fixed Timeout (unlike httpClient's 30s), since a
+	// follow=true log stream or an exec session is meant to live as long
+	// as ctx does, not get cut off mid-stream.
+	streamClient *http.Client
+	retry        *RetryManager
+	opts         ClientOptions
 }
 
+// NewEnvInstanceClient builds a client against baseURL using
+// DefaultClientOptions.
 func NewEnvInstanceClient(baseURL string) *EnvInstanceClient {
+	return NewEnvInstanceClientWithOptions(baseURL, DefaultClientOptions)
+}
+
+// NewEnvInstanceClientWithOptions builds a client against baseURL with a
+// custom retry/breaker configuration. EnvInstanceClient has no qps/burst
+// flag of its own (unlike ScheduleClient), so its RetryManager is backed by
+// an effectively unlimited token bucket - rate limiting stays a concern of
+// the caller (middleware.RateLimit) rather than the backend transport.
+func NewEnvInstanceClientWithOptions(baseURL string, opts ClientOptions) *EnvInstanceClient {
+	policy := RetryPolicy{
+		MaxAttempts:  opts.MaxRetries,
+		InitialDelay: opts.BackoffBase,
+		MaxDelay:     opts.BackoffMax,
+	}
 	return &EnvInstanceClient{
 		baseURL: baseURL,
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		streamClient: &http.Client{},
+		retry:        NewRetryManagerWithBreaker(policy, NewTokenBucket(1e6, 1e6), opts.BreakerThreshold, opts.BreakerCooldown),
+		opts:         opts,
 	}
 }
 
+func init() {
+	RegisterBackend("standard", newEnvInstanceClientBackend)
+}
+
+// newEnvInstanceClientBackend is the "standard" BackendFactory, preserved
+// under its original flag value for backward compatibility.
+func newEnvInstanceClientBackend(cfg map[string]interface{}) (EnvInstanceService, error) {
+	baseURL, _ := cfg["baseURL"].(string)
+	return NewEnvInstanceClient(baseURL), nil
+}
+
+// isRetryableStatus reports whether err carries one of c.opts.RetryableStatus,
+// walking wrapped errors the same way resilience.go's shared isRetryableStatus
+// does, but against this client's own configurable status list instead of
+// the fixed set ScheduleClient uses.
+func (c *EnvInstanceClient) isRetryableStatus(err error) bool {
+	var statusErr *httpStatusError
+	for e := err; e != nil; {
+		if se, ok := e.(*httpStatusError); ok {
+			statusErr = se
+			break
+		}
+		u, ok := e.(interface{ Unwrap() error })
+		if !ok {
+			break
+		}
+		e = u.Unwrap()
+	}
+	if statusErr == nil {
+		return false
+	}
+	for _, s := range c.opts.RetryableStatus {
+		if statusErr.statusCode == s {
+			return true
+		}
+	}
+	return false
+}
+
+// retryableIdempotent allows retries on connection errors or a configured
+// retryable status, for GET/DELETE/PUT-style calls where resending is safe.
+func (c *EnvInstanceClient) retryableIdempotent(err error) bool {
+	var netErr net.Error
+	if asNetError(err, &netErr) {
+		return true
+	}
+	return c.isRetryableStatus(err)
+}
+
+// retryablePostBeforeSend mirrors ScheduleClient.send's POST policy: retry
+// only network errors from before the request reached the server, or a
+// configured retryable status, never an ambiguous mid-write failure.
+func (c *EnvInstanceClient) retryablePostBeforeSend(err error) bool {
+	if isDialError(err) {
+		return true
+	}
+	return c.isRetryableStatus(err)
+}
+
+// do issues an HTTP request against reqURL, retrying through c.retry
+// according to retryable, and returns the response body once the status
+// matches one of okStatuses (defaulting to 200 OK). It auto-fills
+// X-Request-ID from ctx (see WithRequestID) and invokes opts.Tracer once per
+// attempt so failures can be correlated end-to-end with the caller.
+func (c *EnvInstanceClient) do(ctx context.Context, endpoint, method, reqURL string, bodyBytes []byte, retryable func(error) bool, okStatuses ...int) ([]byte, error) {
+	if len(okStatuses) == 0 {
+		okStatuses = []int{http.StatusOK}
+	}
+	requestID := RequestIDFromContext(ctx)
+
+	var respBody []byte
+	err := c.retry.Do(ctx, endpoint, retryable, func() error {
+		var reqBody io.Reader
+		if bodyBytes != nil {
+			reqBody = bytes.NewBuffer(bodyBytes)
+		}
+		httpReq, err := http.NewRequestWithContext(ctx, method, reqURL, reqBody)
+		if err != nil {
+			return &FatalError{Err: fmt.Errorf("failed to create request: %v", err)}
+		}
+		if bodyBytes != nil {
+			httpReq.Header.Set("Content-Type", "application/json")
+		}
+		if requestID != "" {
+			httpReq.Header.Set("X-Request-ID", requestID)
+		}
+		if c.opts.Tracer != nil {
+			c.opts.Tracer(endpoint, requestID)
+		}
+
+		resp, err := c.httpClient.Do(httpReq)
+		if err != nil {
+			return fmt.Errorf("failed to send request: %w", err)
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("failed to read response body: %v", err)
+		}
+
+		ok := false
+		for _, s := range okStatuses {
+			if resp.StatusCode == s {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return newHTTPStatusError(resp.StatusCode, fmt.Errorf("request failed with status: %d, body: %s", resp.StatusCode, truncateBody(body)))
+		}
+
+		respBody = body
+		return nil
+	})
+	return respBody, err
+}
+
 // CreateEnvInstance creates a new environment instance based on the provided environment configuration.
 //
 // Parameters:
+//   - ctx (context.Context): Bounds the request and its retries; cancelling it aborts in-flight attempts.
 //   - req (*backend.Env): The environment configuration used to create the instance.
 //
 // Returns:
 //   - *models.EnvInstance: The created environment instance if successful.
 //   - error: An error if the request fails, including HTTP errors, JSON parsing errors, or service-reported errors.
-func (c *EnvInstanceClient) CreateEnvInstance(req *backend.Env) (*models.EnvInstance, error) {
+//     A circuit breaker trip surfaces as ErrBackendUnavailable.
+func (c *EnvInstanceClient) CreateEnvInstance(ctx context.Context, req *backend.Env) (*models.EnvInstance, error) {
 	url := fmt.Sprintf("%s/%s", c.baseURL, AEnvOpenAPIInstance)
 
 	jsonData, err := req.ToJSON()
 	if err != nil {
 		return nil, fmt.Errorf("create env instance: failed to marshal request: %v", err)
 	}
-	httpReq, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("create env instance: failed to create request: %v", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("create env instance: failed to send request: %v", err)
-	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := c.do(ctx, "create_env_instance", http.MethodPost, url, jsonData, c.retryablePostBeforeSend, http.StatusOK, http.StatusCreated)
 	if err != nil {
-		return nil, fmt.Errorf("create env instance: failed to read response body: %v", err)
-	}
-
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return nil, fmt.Errorf("create env instance: request failed with status %d: %s", resp.StatusCode, truncateBody(body))
+		return nil, fmt.Errorf("create env instance: %w", err)
 	}
 
 	var createResp models.ClientResponse[models.EnvInstance]
@@ -89,32 +284,19 @@ func (c *EnvInstanceClient) CreateEnvInstance(req *backend.Env) (*models.EnvInst
 // GetEnvInstance retrieves an existing environment instance by its ID.
 //
 // Parameters:
+//   - ctx (context.Context): Bounds the request and its retries; cancelling it aborts in-flight attempts.
 //   - id (string): The unique identifier of the environment instance.
 //
 // Returns:
 //   - *models.EnvInstance: The requested environment instance if found.
 //   - error: An error if the instance does not exist, HTTP request fails, or response is invalid.
-func (c *EnvInstanceClient) GetEnvInstance(id string) (*models.EnvInstance, error) {
+//     A circuit breaker trip surfaces as ErrBackendUnavailable.
+func (c *EnvInstanceClient) GetEnvInstance(ctx context.Context, id string) (*models.EnvInstance, error) {
 	url := fmt.Sprintf("%s/%s/%s", c.baseURL, AEnvOpenAPIInstance, id)
 
-	httpReq, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("get env instance %s: failed to create request: %v", id, err)
-	}
-
-	resp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("get env instance %s: failed to send request: %v", id, err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
+	body, err := c.do(ctx, "get_env_instance", http.MethodGet, url, nil, c.retryableIdempotent)
 	if err != nil {
-		return nil, fmt.Errorf("get env instance %s: failed to read response body: %v", id, err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("get env instance %s: request failed with status %d: %s", id, resp.StatusCode, truncateBody(body))
+		return nil, fmt.Errorf("get env instance %s: %w", id, err)
 	}
 
 	var getResp models.ClientResponse[models.EnvInstance]
@@ -132,31 +314,18 @@ func (c *EnvInstanceClient) GetEnvInstance(id string) (*models.EnvInstance, erro
 // DeleteEnvInstance deletes an environment instance by its ID.
 //
 // Parameters:
+//   - ctx (context.Context): Bounds the request and its retries; cancelling it aborts in-flight attempts.
 //   - id (string): The unique identifier of the environment instance to delete.
 //
 // Returns:
-//   - error: nil if deletion is successful; otherwise, an error indicating failure in request, response, or service logic.
-func (c *EnvInstanceClient) DeleteEnvInstance(id string) error {
+//   - error: nil if deletion is successful; otherwise, an error indicating failure in request, response, or
+//     service logic. A circuit breaker trip surfaces as ErrBackendUnavailable.
+func (c *EnvInstanceClient) DeleteEnvInstance(ctx context.Context, id string) error {
 	url := fmt.Sprintf("%s/%s/%s", c.baseURL, AEnvOpenAPIInstance, id)
 
-	httpReq, err := http.NewRequest("DELETE", url, nil)
-	if err != nil {
-		return fmt.Errorf("delete env instance %s: failed to create request: %v", id, err)
-	}
-
-	resp, err := c.httpClient.Do(httpReq)
+	body, err := c.do(ctx, "delete_env_instance", http.MethodDelete, url, nil, c.retryableIdempotent)
 	if err != nil {
-		return fmt.Errorf("delete env instance %s: failed to send request: %v", id, err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("delete env instance %s: failed to read response body: %v", id, err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("delete env instance %s: request failed with status %d: %s", id, resp.StatusCode, truncateBody(body))
+		return fmt.Errorf("delete env instance %s: %w", id, err)
 	}
 
 	var deleteResp models.ClientResponse[bool]
@@ -174,32 +343,19 @@ func (c *EnvInstanceClient) DeleteEnvInstance(id string) error {
 // ListEnvInstances lists environment instances filtered by environment name.
 //
 // Parameters:
+//   - ctx (context.Context): Bounds the request and its retries; cancelling it aborts in-flight attempts.
 //   - envName (string): The name of the environment to filter instances by. Use empty string to list all.
 //
 // Returns:
 //   - []*models.EnvInstance: A slice of matching environment instances.
-//   - error: An error if the request fails, response is invalid, or service reports an error.
-func (c *EnvInstanceClient) ListEnvInstances(envName string) ([]*models.EnvInstance, error) {
+//   - error: An error if the request fails, response is invalid, or service reports an error. A circuit
+//     breaker trip surfaces as ErrBackendUnavailable.
+func (c *EnvInstanceClient) ListEnvInstances(ctx context.Context, envName string) ([]*models.EnvInstance, error) {
 	url := fmt.Sprintf("%s/%s?envName=%s", c.baseURL, AEnvOpenAPIInstance, envName)
 
-	httpReq, err := http.NewRequest("GET", url, nil)
+	body, err := c.do(ctx, "list_env_instances", http.MethodGet, url, nil, c.retryableIdempotent)
 	if err != nil {
-		return nil, fmt.Errorf("list env instances: failed to create request: %v", err)
-	}
-
-	resp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("list env instances: failed to send request: %v", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("list env instances: failed to read response body: %v", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("list env instances: request failed with status %d: %s", resp.StatusCode, truncateBody(body))
+		return nil, fmt.Errorf("list env instances: %w", err)
 	}
 
 	var getResp models.ClientResponse[[]*models.EnvInstance]
@@ -217,31 +373,19 @@ func (c *EnvInstanceClient) ListEnvInstances(envName string) ([]*models.EnvInsta
 // Warmup triggers a warm-up process for the environment to prepare resources in advance.
 //
 // Parameters:
+//   - ctx (context.Context): Bounds the request and its retries; cancelling it aborts in-flight attempts.
 //   - req (*backend.Env): The environment configuration used for warm-up preparation.
 //
 // Returns:
-//   - error: nil if warm-up is successful; otherwise, an error describing the failure.
-func (c *EnvInstanceClient) Warmup(req *backend.Env) error {
+//   - error: nil if warm-up is successful; otherwise, an error describing the failure. A circuit
+//     breaker trip surfaces as ErrBackendUnavailable. PUT is treated as idempotent here, since
+//     warmup only ever raises the target pool size and resending is safe.
+func (c *EnvInstanceClient) Warmup(ctx context.Context, req *backend.Env) error {
 	url := fmt.Sprintf("%s/%s/action/warmup", c.baseURL, AEnvOpenAPIInstance)
 
-	httpReq, err := http.NewRequest("PUT", url, nil)
-	if err != nil {
-		return fmt.Errorf("warmup env: failed to create request: %v", err)
-	}
-
-	resp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return fmt.Errorf("warmup env: failed to send request: %v", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
+	body, err := c.do(ctx, "warmup_env", http.MethodPut, url, nil, c.retryableIdempotent)
 	if err != nil {
-		return fmt.Errorf("warmup env: failed to read response body: %v", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("warmup env: request failed with status %d: %s", resp.StatusCode, truncateBody(body))
+		return fmt.Errorf("warmup env: %w", err)
 	}
 
 	var getResp models.ClientResponse[models.EnvInstance]
@@ -259,43 +403,187 @@ func (c *EnvInstanceClient) Warmup(req *backend.Env) error {
 // Cleanup performs a cleanup operation to release unused environment resources.
 //
 // Parameters:
-//   - None
+//   - ctx (context.Context): Bounds the request and its retries; cancelling it aborts in-flight attempts.
 //
 // Returns:
-//   - error: nil if cleanup is successful; otherwise, an error indicating failure.
-func (c *EnvInstanceClient) Cleanup() error {
+//   - error: nil if cleanup is successful; otherwise, an error indicating failure. A circuit breaker
+//     trip surfaces as ErrBackendUnavailable. PUT is treated as idempotent, matching Warmup.
+func (c *EnvInstanceClient) Cleanup(ctx context.Context) error {
 	url := fmt.Sprintf("%s/%s/action/cleanup", c.baseURL, AEnvOpenAPIInstance)
 
-	httpReq, err := http.NewRequest("PUT", url, nil)
+	body, err := c.do(ctx, "cleanup_env", http.MethodPut, url, nil, c.retryableIdempotent)
 	if err != nil {
-		return fmt.Errorf("cleanup env: failed to create request: %v", err)
+		return fmt.Errorf("cleanup env: %w", err)
+	}
+
+	var getResp models.ClientResponse[models.EnvInstance]
+	if err := json.Unmarshal(body, &getResp); err != nil {
+		return fmt.Errorf("cleanup env: failed to unmarshal response: %v", err)
+	}
+
+	if !getResp.Success {
+		return fmt.Errorf("cleanup env: server returned error, code: %d", getResp.Code)
 	}
 
-	resp, err := c.httpClient.Do(httpReq)
+	return nil
+}
+
+// StreamLogs streams the instance's logs from
+// aenvironment/instance/:id/logs. The request is bound to ctx, so cancelling
+// ctx (e.g. because the caller's own HTTP connection dropped) aborts the
+// underlying request and unblocks any in-progress Read on the returned body.
+func (c *EnvInstanceClient) StreamLogs(ctx context.Context, id string, opts LogOptions) (io.ReadCloser, error) {
+	reqURL := fmt.Sprintf("%s/%s/%s/logs", c.baseURL, AEnvOpenAPIInstance, id)
+
+	query := url.Values{}
+	if opts.Follow {
+		query.Set("follow", "true")
+	}
+	if opts.Tail > 0 {
+		query.Set("lines", strconv.Itoa(opts.Tail))
+	}
+	if !opts.Since.IsZero() {
+		query.Set("since", opts.Since.UTC().Format(time.RFC3339))
+	}
+	if encoded := query.Encode(); encoded != "" {
+		reqURL += "?" + encoded
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
 	if err != nil {
-		return fmt.Errorf("cleanup env: failed to send request: %v", err)
+		return nil, fmt.Errorf("stream logs %s: failed to create request: %v", id, err)
+	}
+	if requestID := RequestIDFromContext(ctx); requestID != "" {
+		httpReq.Header.Set("X-Request-ID", requestID)
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	resp, err := c.streamClient.Do(httpReq)
 	if err != nil {
-		return fmt.Errorf("cleanup env: failed to read response body: %v", err)
+		return nil, fmt.Errorf("stream logs %s: failed to send request: %v", id, err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("cleanup env: request failed with status %d: %s", resp.StatusCode, truncateBody(body))
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, fmt.Errorf("stream logs %s: request failed with status %d: %s", id, resp.StatusCode, truncateBody(body))
 	}
 
-	var getResp models.ClientResponse[models.EnvInstance]
-	if err := json.Unmarshal(body, &getResp); err != nil {
-		return fmt.Errorf("cleanup env: failed to unmarshal response: %v", err)
+	return resp.Body, nil
+}
+
+// Exec opens a bidirectional WebSocket session against
+// aenvironment/instance/:id/exec, writes cmd as the session's initial
+// frame, pumps stdin into the socket in the background, and returns the
+// combined stdout/stderr as an ExecStream. Cancelling ctx closes the
+// socket, ending both directions.
+func (c *EnvInstanceClient) Exec(ctx context.Context, id string, cmd []string, stdin io.Reader) (ExecStream, error) {
+	wsURL, err := execWebSocketURL(c.baseURL, id)
+	if err != nil {
+		return nil, fmt.Errorf("exec %s: %v", id, err)
 	}
 
-	if !getResp.Success {
-		return fmt.Errorf("cleanup env: server returned error, code: %d", getResp.Code)
+	header := http.Header{}
+	if requestID := RequestIDFromContext(ctx); requestID != "" {
+		header.Set("X-Request-ID", requestID)
+	}
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, wsURL, header)
+	if err != nil {
+		return nil, fmt.Errorf("exec %s: failed to dial: %v", id, err)
 	}
 
-	return nil
+	if err := conn.WriteJSON(&execCommandFrame{Cmd: cmd}); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("exec %s: failed to send command: %v", id, err)
+	}
+
+	stream := newWebSocketExecStream(conn, stdin)
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+	return stream, nil
+}
+
+// execCommandFrame is the first frame an Exec session writes, telling the
+// server what to run before either side starts streaming.
+type execCommandFrame struct {
+	Cmd []string `json:"cmd"`
+}
+
+// execWebSocketURL rewrites baseURL's scheme to ws/wss and appends the
+// instance's exec path.
+func execWebSocketURL(baseURL, id string) (string, error) {
+	u, err := url.Parse(fmt.Sprintf("%s/%s/%s/exec", baseURL, AEnvOpenAPIInstance, id))
+	if err != nil {
+		return "", fmt.Errorf("invalid base url: %v", err)
+	}
+	if u.Scheme == "https" {
+		u.Scheme = "wss"
+	} else {
+		u.Scheme = "ws"
+	}
+	return u.String(), nil
+}
+
+// webSocketExecStream adapts a gorilla/websocket connection to ExecStream:
+// Read drains one websocket message at a time into the caller's buffer, and
+// a background goroutine forwards stdin onto the same connection as binary
+// frames.
+type webSocketExecStream struct {
+	conn    *websocket.Conn
+	pending []byte
+}
+
+func newWebSocketExecStream(conn *websocket.Conn, stdin io.Reader) *webSocketExecStream {
+	s := &webSocketExecStream{conn: conn}
+	if stdin != nil {
+		go s.pumpStdin(stdin)
+	}
+	return s
+}
+
+func (s *webSocketExecStream) pumpStdin(stdin io.Reader) {
+	buf := make([]byte, 4096)
+	for {
+		n, err := stdin.Read(buf)
+		if n > 0 {
+			if writeErr := s.conn.WriteMessage(websocket.BinaryMessage, buf[:n]); writeErr != nil {
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (s *webSocketExecStream) Read(p []byte) (int, error) {
+	for len(s.pending) == 0 {
+		_, data, err := s.conn.ReadMessage()
+		if err != nil {
+			return 0, err
+		}
+		s.pending = data
+	}
+	n := copy(p, s.pending)
+	s.pending = s.pending[n:]
+	return n, nil
+}
+
+func (s *webSocketExecStream) Close() error {
+	return s.conn.Close()
+}
+
+// BatchDeleteEnvInstances implements EnvInstanceService interface, fanning
+// out to DeleteEnvInstance through a bounded worker pool.
+func (c *EnvInstanceClient) BatchDeleteEnvInstances(ctx context.Context, ids []string, concurrency int) (map[string]error, error) {
+	return batchDeleteEnvInstances(ctx, ids, concurrency, c.DeleteEnvInstance)
+}
+
+// BatchCreateEnvInstances implements EnvInstanceService interface, fanning
+// out to CreateEnvInstance through a bounded worker pool.
+func (c *EnvInstanceClient) BatchCreateEnvInstances(ctx context.Context, reqs []*backend.Env, concurrency int) (map[string]*models.EnvInstance, map[string]error, error) {
+	return batchCreateEnvInstances(ctx, reqs, concurrency, c.CreateEnvInstance)
 }
 
 // truncateBody truncate body for memory protection
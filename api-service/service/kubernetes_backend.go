@@ -0,0 +1,307 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"api-service/models"
+	backend "envhub/models"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+func init() {
+	RegisterBackend("kubernetes", newKubernetesBackendFromConfig)
+}
+
+// newKubernetesBackendFromConfig is the "kubernetes" BackendFactory. It
+// follows the same kubeconfig-or-in-cluster resolution AEnvPodHandler uses
+// for the local cluster, letting operators point api-service straight at an
+// apiserver without standing up the intermediary envhub/aenvhub service.
+func newKubernetesBackendFromConfig(cfg map[string]interface{}) (EnvInstanceService, error) {
+	kubeconfig, _ := cfg["kubeconfig"].(string)
+	namespace, _ := cfg["namespace"].(string)
+	if namespace == "" {
+		namespace = "default"
+	}
+	return NewKubernetesBackend(kubeconfig, namespace)
+}
+
+// KubernetesBackend is a direct, client-go-backed EnvInstanceService: it
+// creates/deletes the env instance's Pod itself rather than calling out to
+// aenvhub over HTTP (ScheduleClient) or the envhub instance API
+// (EnvInstanceClient).
+type KubernetesBackend struct {
+	clientset kubernetes.Interface
+	config    *rest.Config
+	namespace string
+}
+
+// NewKubernetesBackend builds a KubernetesBackend for namespace. kubeconfig
+// may be empty, in which case in-cluster config is used, falling back to
+// $KUBECONFIG for local testing - the same resolution order
+// AEnvPodHandler.NewAEnvPodHandler uses.
+func NewKubernetesBackend(kubeconfig, namespace string) (*KubernetesBackend, error) {
+	config, err := rest.InClusterConfig()
+	if kubeconfig != "" || err != nil {
+		if kubeconfig == "" {
+			kubeconfig = os.Getenv("KUBECONFIG")
+		}
+		config, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
+		if err != nil {
+			return nil, fmt.Errorf("kubernetes backend: failed to build kubeconfig: %v", err)
+		}
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("kubernetes backend: failed to create clientset: %v", err)
+	}
+
+	return &KubernetesBackend{clientset: clientset, config: config, namespace: namespace}, nil
+}
+
+// CreateEnvInstance creates the env instance's Pod directly via client-go,
+// rendering it from the same DeployConfig fields
+// api-service/controller.EnvInstanceController.CreateEnvInstance already
+// populates (secondImageName, environmentVariables, arguments, ttl).
+func (b *KubernetesBackend) CreateEnvInstance(ctx context.Context, req *backend.Env) (*models.EnvInstance, error) {
+	pod := podFromEnv(req, b.namespace)
+	created, err := b.clientset.CoreV1().Pods(b.namespace).Create(ctx, pod, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("kubernetes backend: failed to create pod: %v", err)
+	}
+	return instanceFromPod(created), nil
+}
+
+// GetEnvInstance looks up the Pod backing id by name.
+func (b *KubernetesBackend) GetEnvInstance(ctx context.Context, id string) (*models.EnvInstance, error) {
+	pod, err := b.clientset.CoreV1().Pods(b.namespace).Get(ctx, id, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("kubernetes backend: failed to get pod %s: %v", id, err)
+	}
+	return instanceFromPod(pod), nil
+}
+
+// DeleteEnvInstance deletes the Pod backing id. A missing pod is treated as
+// an already-completed delete rather than an error, matching
+// ScheduleClient.DeleteEnvInstance's idempotent-delete expectations.
+func (b *KubernetesBackend) DeleteEnvInstance(ctx context.Context, id string) error {
+	err := b.clientset.CoreV1().Pods(b.namespace).Delete(ctx, id, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("kubernetes backend: failed to delete pod %s: %v", id, err)
+	}
+	return nil
+}
+
+// ListEnvInstances lists every pod in the namespace, optionally filtered by
+// the aenv-env label CreateEnvInstance stamps on from req.Name.
+func (b *KubernetesBackend) ListEnvInstances(ctx context.Context, envName string) ([]*models.EnvInstance, error) {
+	opts := metav1.ListOptions{}
+	if envName != "" {
+		opts.LabelSelector = fmt.Sprintf("aenv-env=%s", envName)
+	}
+	podList, err := b.clientset.CoreV1().Pods(b.namespace).List(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("kubernetes backend: failed to list pods: %v", err)
+	}
+	instances := make([]*models.EnvInstance, 0, len(podList.Items))
+	for i := range podList.Items {
+		instances = append(instances, instanceFromPod(&podList.Items[i]))
+	}
+	return instances, nil
+}
+
+// Warmup is a no-op: every KubernetesBackend.CreateEnvInstance call is
+// already a real, synchronous pod creation, so there is no separate warm
+// pool for this backend to pre-stage (see WarmPoolManager for that layered
+// on top of EnvInstanceService instead).
+func (b *KubernetesBackend) Warmup(ctx context.Context, req *backend.Env) error {
+	return nil
+}
+
+// Cleanup is a no-op: TTL expiry for pods created this way is the
+// controller module's job (PodReaper / TTLGCController), not
+// api-service's - this backend only ever talks to the apiserver directly
+// and has no separate expiry bookkeeping of its own to sweep.
+func (b *KubernetesBackend) Cleanup(ctx context.Context) error {
+	return nil
+}
+
+// StreamLogs streams the pod's logs straight from the apiserver; ctx
+// cancellation closes the underlying stream the same way it does for
+// EnvInstanceClient's HTTP-based implementation.
+func (b *KubernetesBackend) StreamLogs(ctx context.Context, id string, opts LogOptions) (io.ReadCloser, error) {
+	logOpts := &corev1.PodLogOptions{Follow: opts.Follow}
+	if opts.Tail > 0 {
+		tail := int64(opts.Tail)
+		logOpts.TailLines = &tail
+	}
+	if !opts.Since.IsZero() {
+		sinceTime := metav1.NewTime(opts.Since)
+		logOpts.SinceTime = &sinceTime
+	}
+
+	stream, err := b.clientset.CoreV1().Pods(b.namespace).GetLogs(id, logOpts).Stream(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("kubernetes backend: failed to stream logs for pod %s: %v", id, err)
+	}
+	return stream, nil
+}
+
+// Exec runs cmd in the pod's first container via the apiserver's exec
+// subresource, returning the combined stdout/stderr over a pipe fed by a
+// background goroutine; closing the returned ExecStream (or cancelling ctx)
+// ends the executor.Stream call and releases that goroutine.
+func (b *KubernetesBackend) Exec(ctx context.Context, id string, cmd []string, stdin io.Reader) (ExecStream, error) {
+	pod, err := b.clientset.CoreV1().Pods(b.namespace).Get(ctx, id, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("kubernetes backend: failed to get pod %s: %v", id, err)
+	}
+	if len(pod.Spec.Containers) == 0 {
+		return nil, fmt.Errorf("kubernetes backend: pod %s has no containers", id)
+	}
+
+	req := b.clientset.CoreV1().RESTClient().Post().
+		Namespace(b.namespace).
+		Resource("pods").
+		Name(id).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: pod.Spec.Containers[0].Name,
+			Command:   cmd,
+			Stdin:     stdin != nil,
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec)
+
+	executor, err := remotecommand.NewSPDYExecutor(b.config, "POST", req.URL())
+	if err != nil {
+		return nil, fmt.Errorf("kubernetes backend: failed to build executor for pod %s: %v", id, err)
+	}
+
+	pr, pw := io.Pipe()
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		err := executor.StreamWithContext(ctx, remotecommand.StreamOptions{
+			Stdin:  stdin,
+			Stdout: pw,
+			Stderr: pw,
+		})
+		pw.CloseWithError(err)
+	}()
+
+	return &pipeExecStream{PipeReader: pr, cancel: cancel}, nil
+}
+
+// pipeExecStream adapts executor.StreamWithContext's push-based output onto
+// the pull-based ExecStream/io.ReadCloser contract, cancelling the
+// underlying exec session on Close.
+type pipeExecStream struct {
+	*io.PipeReader
+	cancel context.CancelFunc
+}
+
+func (s *pipeExecStream) Close() error {
+	s.cancel()
+	return s.PipeReader.Close()
+}
+
+// BatchDeleteEnvInstances implements EnvInstanceService interface, fanning
+// out to DeleteEnvInstance through a bounded worker pool.
+func (b *KubernetesBackend) BatchDeleteEnvInstances(ctx context.Context, ids []string, concurrency int) (map[string]error, error) {
+	return batchDeleteEnvInstances(ctx, ids, concurrency, b.DeleteEnvInstance)
+}
+
+// BatchCreateEnvInstances implements EnvInstanceService interface, fanning
+// out to CreateEnvInstance through a bounded worker pool.
+func (b *KubernetesBackend) BatchCreateEnvInstances(ctx context.Context, reqs []*backend.Env, concurrency int) (map[string]*models.EnvInstance, map[string]error, error) {
+	return batchCreateEnvInstances(ctx, reqs, concurrency, b.CreateEnvInstance)
+}
+
+// podFromEnv renders req's DeployConfig into a single-container Pod,
+// covering the fields api-service/controller.EnvInstanceController.CreateEnvInstance
+// actually sets: secondImageName, environmentVariables, arguments and ttl.
+func podFromEnv(req *backend.Env, namespace string) *corev1.Pod {
+	image := req.Content.Image
+	if v, ok := req.DeployConfig["secondImageName"].(string); ok && v != "" {
+		image = v
+	}
+
+	var envVars []corev1.EnvVar
+	if envMap, ok := req.DeployConfig["environmentVariables"].(map[string]string); ok {
+		for k, v := range envMap {
+			envVars = append(envVars, corev1.EnvVar{Name: k, Value: v})
+		}
+	}
+
+	var args []string
+	if v, ok := req.DeployConfig["arguments"].([]string); ok {
+		args = v
+	}
+
+	labels := map[string]string{"aenv-env": req.Name}
+	var finalizers []string
+	if ttl, ok := req.DeployConfig["ttl"].(string); ok && ttl != "" {
+		labels["aenv-ttl"] = ttl
+		finalizers = append(finalizers, "aenv.io/ttl-protection")
+	}
+
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: fmt.Sprintf("%s-", req.Name),
+			Namespace:    namespace,
+			Labels:       labels,
+			Finalizers:   finalizers,
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			Containers: []corev1.Container{
+				{
+					Name:  "main",
+					Image: image,
+					Args:  args,
+					Env:   envVars,
+				},
+			},
+		},
+	}
+}
+
+// instanceFromPod adapts a Pod into the models.EnvInstance shape every other
+// EnvInstanceService implementation returns, so callers (EnvInstanceController,
+// the Redis cache, WarmPoolManager) can treat backends interchangeably.
+func instanceFromPod(pod *corev1.Pod) *models.EnvInstance {
+	return &models.EnvInstance{
+		ID:        pod.Name,
+		Name:      pod.Labels["aenv-env"],
+		Status:    string(pod.Status.Phase),
+		CreatedAt: pod.CreationTimestamp.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,443 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// requestIDKey is the context key WithRequestID/RequestIDFromContext use, so
+// a caller that already resolved or generated a request ID for logging can
+// thread it down to the client that sets the X-Request-ID header.
+type requestIDKey struct{}
+
+// WithRequestID attaches id to ctx for RequestIDFromContext to retrieve
+// later. Controllers call this once per incoming request, typically from a
+// value already assigned by upstream middleware or the gin request itself.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID attached via WithRequestID, or
+// "" if none was set.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// TokenBucket bounds the rate of requests ScheduleClient sends to the
+// aenvhub server (fillRate per second, bursts of up to burst), mirroring the qps/burst flags already used for
+// the k8s client in controller/cmd/main.go's SetUpController.
+type TokenBucket struct {
+	mu         sync.Mutex
+	fillRate   float64 // tokens added per second
+	burst      float64
+	tokens     float64
+	lastRefill time.Time
+}
+
+// NewTokenBucket creates a bucket that refills at fillRate tokens/sec up to burst.
+func NewTokenBucket(fillRate float64, burst int) *TokenBucket {
+	return &TokenBucket{
+		fillRate:   fillRate,
+		burst:      float64(burst),
+		tokens:     float64(burst),
+		lastRefill: time.Now(),
+	}
+}
+
+func (b *TokenBucket) refill() {
+	now := time.Now()
+	elapsed := now.Sub(b.lastRefill).Seconds()
+	b.tokens = minFloat(b.burst, b.tokens+elapsed*b.fillRate)
+	b.lastRefill = now
+}
+
+// Wait blocks until a token is available or ctx is cancelled.
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		b.refill()
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mu.Unlock()
+			return nil
+		}
+		wait := time.Duration((1 - b.tokens) / b.fillRate * float64(time.Second))
+		b.mu.Unlock()
+
+		if wait <= 0 {
+			wait = time.Millisecond
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// FatalError marks an error as non-retryable, analogous to
+// ImageChangeControllerFatalError: once classified fatal, the retry loop
+// short-circuits instead of burning further attempts/backoff.
+type FatalError struct {
+	Err error
+}
+
+func (e *FatalError) Error() string { return e.Err.Error() }
+func (e *FatalError) Unwrap() error { return e.Err }
+
+// IsFatal reports whether err was wrapped as a FatalError.
+func IsFatal(err error) bool {
+	_, ok := err.(*FatalError)
+	return ok
+}
+
+// breakerState is the state of a single-endpoint circuit breaker.
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// CircuitBreaker trips after consecutiveFailures in a row and refuses calls
+// until cooldown has elapsed, at which point it goes half-open and lets calls
+// through; the next success closes it, the next failure opens it again.
+type CircuitBreaker struct {
+	mu                  sync.Mutex
+	endpoint            string
+	consecutiveFailures int
+	threshold           int
+	cooldown            time.Duration
+	state               breakerState
+	openedAt            time.Time
+}
+
+// NewCircuitBreaker creates a breaker that opens after threshold consecutive
+// failures and half-opens after cooldown.
+func NewCircuitBreaker(endpoint string, threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{endpoint: endpoint, threshold: threshold, cooldown: cooldown}
+}
+
+// Allow reports whether a call may proceed, transitioning open -> half-open
+// once the cooldown has elapsed.
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if time.Since(b.openedAt) >= b.cooldown {
+			b.state = breakerHalfOpen
+			scheduleClientBreakerState.WithLabelValues(b.endpoint).Set(float64(breakerHalfOpen))
+			return true
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+// RecordSuccess closes the breaker.
+func (b *CircuitBreaker) RecordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.consecutiveFailures = 0
+	b.state = breakerClosed
+	scheduleClientBreakerState.WithLabelValues(b.endpoint).Set(float64(breakerClosed))
+}
+
+// RecordFailure counts a failure, opening the breaker once threshold is reached.
+func (b *CircuitBreaker) RecordFailure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.consecutiveFailures++
+	if b.consecutiveFailures >= b.threshold {
+		b.state = breakerOpen
+		b.openedAt = time.Now()
+		scheduleClientBreakerState.WithLabelValues(b.endpoint).Set(float64(breakerOpen))
+	}
+}
+
+// ErrBackendUnavailable is returned when a call is rejected because its
+// circuit breaker is open, i.e. the backend has failed enough consecutive
+// times recently that callers should back off rather than retry immediately.
+// Callers can check for it with errors.Is to distinguish "temporarily down,
+// retry later" from an ordinary request failure.
+var ErrBackendUnavailable = &FatalError{Err: fmt.Errorf("backend unavailable: circuit breaker open")}
+
+// RetryPolicy configures RetryManager.
+type RetryPolicy struct {
+	MaxAttempts  int
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+}
+
+// DefaultRetryPolicy matches the 30s timeout ScheduleClient already used, now
+// spread across a handful of backed-off attempts instead of a single shot.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:  4,
+	InitialDelay: 200 * time.Millisecond,
+	MaxDelay:     5 * time.Second,
+}
+
+// RetryManager retries idempotent verbs with exponential backoff + jitter,
+// bounded by a token bucket and a per-endpoint circuit breaker, and aborts
+// immediately on a FatalError.
+type RetryManager struct {
+	policy           RetryPolicy
+	limiter          *TokenBucket
+	breakerThreshold int
+	breakerCooldown  time.Duration
+	breakers         sync.Map // endpoint -> *CircuitBreaker
+}
+
+// NewRetryManager creates a RetryManager sharing one token bucket across all
+// endpoints, with a breaker that opens after 5 consecutive failures and
+// cools down for 30s.
+func NewRetryManager(policy RetryPolicy, limiter *TokenBucket) *RetryManager {
+	return NewRetryManagerWithBreaker(policy, limiter, 5, 30*time.Second)
+}
+
+// NewRetryManagerWithBreaker is NewRetryManager with the breaker's
+// consecutive-failure threshold and cooldown configurable, for callers
+// (e.g. EnvInstanceClient's ClientOptions) that need their own values.
+func NewRetryManagerWithBreaker(policy RetryPolicy, limiter *TokenBucket, breakerThreshold int, breakerCooldown time.Duration) *RetryManager {
+	return &RetryManager{policy: policy, limiter: limiter, breakerThreshold: breakerThreshold, breakerCooldown: breakerCooldown}
+}
+
+func (m *RetryManager) breakerFor(endpoint string) *CircuitBreaker {
+	v, _ := m.breakers.LoadOrStore(endpoint, NewCircuitBreaker(endpoint, m.breakerThreshold, m.breakerCooldown))
+	return v.(*CircuitBreaker)
+}
+
+// Do runs fn, making at most policy.MaxAttempts attempts in all while fn
+// returns a retryable error. retryable is consulted on every non-nil, non-fatal error;
+// idempotent verbs should pass a retryable func that also allows 5xx/network
+// errors, while POST should only allow connect-before-send failures.
+func (m *RetryManager) Do(ctx context.Context, endpoint string, retryable func(error) bool, fn func() error) error {
+	breaker := m.breakerFor(endpoint)
+
+	var lastErr error
+	for attempt := 0; attempt < m.policy.MaxAttempts; attempt++ {
+		if !breaker.Allow() {
+			scheduleClientRequestsTotal.WithLabelValues(endpoint, "breaker_open").Inc()
+			return fmt.Errorf("%s: %w", endpoint, ErrBackendUnavailable)
+		}
+		if err := m.limiter.Wait(ctx); err != nil {
+			return fmt.Errorf("%s: rate limiter wait cancelled: %v", endpoint, err)
+		}
+
+		scheduleClientInFlight.WithLabelValues(endpoint).Inc()
+		err := fn()
+		scheduleClientInFlight.WithLabelValues(endpoint).Dec()
+
+		if err == nil {
+			breaker.RecordSuccess()
+			scheduleClientRequestsTotal.WithLabelValues(endpoint, "success").Inc()
+			return nil
+		}
+
+		lastErr = err
+		if IsFatal(err) || !retryable(err) {
+			breaker.RecordFailure()
+			scheduleClientRequestsTotal.WithLabelValues(endpoint, "failure").Inc()
+			return err
+		}
+
+		breaker.RecordFailure()
+		scheduleClientRetriesTotal.WithLabelValues(endpoint).Inc()
+
+		if attempt == m.policy.MaxAttempts-1 {
+			break
+		}
+		delay := backoffWithJitter(m.policy.InitialDelay, m.policy.MaxDelay, attempt)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+	scheduleClientRequestsTotal.WithLabelValues(endpoint, "failure").Inc()
+	return lastErr
+}
+
+func backoffWithJitter(initial, max time.Duration, attempt int) time.Duration {
+	d := initial << attempt
+	if d <= 0 || d > max {
+		d = max
+	}
+	jitter := time.Duration(rand.Int63n(int64(d) / 2))
+	return d/2 + jitter
+}
+
+// retryableIdempotent allows retries on connection errors and 5xx responses,
+// used for GET/DELETE/PUT-style calls where resending is always safe.
+func retryableIdempotent(err error) bool {
+	return isRetryableTransportOrStatus(err)
+}
+
+// retryablePostBeforeSend allows retries only when the failure is a network
+// error that happened establishing the connection (the request body was
+// never written), or a 5xx from a server that is known idempotent on create
+// retries via RandString-suffixed names. It never retries on ambiguous
+// mid-write failures.
+func retryablePostBeforeSend(err error) bool {
+	if isDialError(err) {
+		return true
+	}
+	return isRetryableStatus(err)
+}
+
+// isDialError reports whether err wraps a *net.OpError from dialing, i.e. a
+// failure before any part of the request was written.
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+func asNetError(err error, target *net.Error) bool {
+	for err != nil {
+		if ne, ok := err.(net.Error); ok {
+			*target = ne
+			return true
+		}
+		unwrapper, ok := err.(interface{ Unwrap() error })
+		if !ok {
+			return false
+		}
+		err = unwrapper.Unwrap()
+	}
+	return false
+}
+
+func isRetryableTransportOrStatus(err error) bool {
+	var netErr net.Error
+	if asNetError(err, &netErr) {
+		return true
+	}
+	return isRetryableStatus(err)
+}
+
+// httpStatusError carries the HTTP status code of a failed request so
+// isRetryableStatus can classify it without reparsing error strings.
+type httpStatusError struct {
+	statusCode int
+	err        error
+}
+
+func (e *httpStatusError) Error() string { return e.err.Error() }
+func (e *httpStatusError) Unwrap() error { return e.err }
+
+func newHTTPStatusError(statusCode int, err error) error {
+	return &httpStatusError{statusCode: statusCode, err: err}
+}
+
+func isRetryableStatus(err error) bool {
+	var statusErr *httpStatusError
+	for e := err; e != nil; {
+		if se, ok := e.(*httpStatusError); ok {
+			statusErr = se
+			break
+		}
+		u, ok := e.(interface{ Unwrap() error })
+		if !ok {
+			break
+		}
+		e = u.Unwrap()
+	}
+	if statusErr == nil {
+		return false
+	}
+	switch statusErr.statusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+const resilienceMetricsNamespace = "aenv_api_service"
+
+var (
+	scheduleClientRequestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: resilienceMetricsNamespace,
+			Name:      "schedule_client_requests_total",
+			Help:      "Total ScheduleClient requests by endpoint and outcome.",
+		},
+		[]string{"endpoint", "outcome"},
+	)
+	scheduleClientRetriesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: resilienceMetricsNamespace,
+			Name:      "schedule_client_retries_total",
+			Help:      "Total ScheduleClient retry attempts by endpoint.",
+		},
+		[]string{"endpoint"},
+	)
+	scheduleClientBreakerState = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: resilienceMetricsNamespace,
+			Name:      "schedule_client_breaker_state",
+			Help:      "Circuit breaker state per endpoint (0=closed, 1=open, 2=half-open).",
+		},
+		[]string{"endpoint"},
+	)
+	scheduleClientInFlight = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: resilienceMetricsNamespace,
+			Name:      "schedule_client_in_flight",
+			Help:      "In-flight ScheduleClient requests per endpoint.",
+		},
+		[]string{"endpoint"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(scheduleClientRequestsTotal, scheduleClientRetriesTotal, scheduleClientBreakerState, scheduleClientInFlight)
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker_OpensAfterThresholdFailures(t *testing.T) {
+	b := NewCircuitBreaker("ep", 3, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		b.RecordFailure()
+		if !b.Allow() {
+			t.Fatalf("breaker should still allow calls before the threshold is reached (failure %d)", i+1)
+		}
+	}
+	b.RecordFailure()
+	if b.Allow() {
+		t.Fatal("expected the breaker to refuse calls once the failure threshold is reached")
+	}
+}
+
+func TestCircuitBreaker_HalfOpensAfterCooldown(t *testing.T) {
+	b := NewCircuitBreaker("ep", 1, time.Millisecond)
+
+	b.RecordFailure()
+	if b.Allow() {
+		t.Fatal("expected the breaker to be open immediately after tripping")
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	if !b.Allow() {
+		t.Fatal("expected the breaker to half-open and allow a probe once cooldown has elapsed")
+	}
+}
+
+func TestCircuitBreaker_RecordSuccessCloses(t *testing.T) {
+	b := NewCircuitBreaker("ep", 1, time.Minute)
+	b.RecordFailure()
+	if b.Allow() {
+		t.Fatal("expected the breaker to be open after tripping")
+	}
+
+	b.RecordSuccess()
+	if !b.Allow() {
+		t.Fatal("expected the breaker to allow calls again after RecordSuccess closes it")
+	}
+}
+
+func TestBackoffWithJitter_StaysWithinMax(t *testing.T) {
+	initial := 100 * time.Millisecond
+	max := time.Second
+	for attempt := 0; attempt < 10; attempt++ {
+		d := backoffWithJitter(initial, max, attempt)
+		if d > max {
+			t.Fatalf("attempt %d: backoff %v exceeded max %v", attempt, d, max)
+		}
+		if d <= 0 {
+			t.Fatalf("attempt %d: backoff must be positive, got %v", attempt, d)
+		}
+	}
+}
+
+func TestTokenBucket_WaitConsumesAndRefills(t *testing.T) {
+	b := NewTokenBucket(1000, 1)
+	ctx := context.Background()
+
+	if err := b.Wait(ctx); err != nil {
+		t.Fatalf("expected the first Wait to consume the burst token immediately, got %v", err)
+	}
+	if err := b.Wait(ctx); err != nil {
+		t.Fatalf("expected a fast refill (1000/sec) to satisfy the second Wait, got %v", err)
+	}
+}
+
+func TestTokenBucket_WaitRespectsContextCancellation(t *testing.T) {
+	b := NewTokenBucket(0.001, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := b.Wait(ctx); err == nil {
+		t.Fatal("expected Wait to return an error once the context is cancelled while starved of tokens")
+	}
+}
+
+func TestRetryManager_Do_SucceedsWithoutRetry(t *testing.T) {
+	m := NewRetryManager(DefaultRetryPolicy, NewTokenBucket(1000, 10))
+	calls := 0
+
+	err := m.Do(context.Background(), "ep-success", retryableIdempotent, func() error {
+		calls++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("expected exactly one call on success, got %d", calls)
+	}
+}
+
+func TestRetryManager_Do_StopsImmediatelyOnFatalError(t *testing.T) {
+	m := NewRetryManager(DefaultRetryPolicy, NewTokenBucket(1000, 10))
+	calls := 0
+	fatal := &FatalError{Err: errors.New("not retryable")}
+
+	err := m.Do(context.Background(), "ep-fatal", retryableIdempotent, func() error {
+		calls++
+		return fatal
+	})
+	if !errors.Is(err, fatal) && !IsFatal(err) {
+		t.Fatalf("expected the fatal error to propagate, got %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("expected a FatalError to abort after exactly one call, got %d calls", calls)
+	}
+}
+
+func TestRetryManager_Do_RetriesRetryableErrorUpToMaxAttempts(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, InitialDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+	m := NewRetryManager(policy, NewTokenBucket(1000, 10))
+	calls := 0
+	retryErr := newHTTPStatusError(503, errors.New("unavailable"))
+
+	err := m.Do(context.Background(), "ep-retry", retryableIdempotent, func() error {
+		calls++
+		return retryErr
+	})
+	if err == nil {
+		t.Fatal("expected an error after exhausting all retry attempts")
+	}
+	if calls != policy.MaxAttempts {
+		t.Fatalf("expected exactly %d calls (one per attempt), got %d", policy.MaxAttempts, calls)
+	}
+}
+
+func TestRetryManager_Do_TripsBreakerAfterRepeatedFailures(t *testing.T) {
+	limiter := NewTokenBucket(1000, 10)
+	m := NewRetryManagerWithBreaker(RetryPolicy{MaxAttempts: 1, InitialDelay: time.Millisecond, MaxDelay: time.Millisecond}, limiter, 1, time.Minute)
+
+	failErr := errors.New("boom")
+	_ = m.Do(context.Background(), "ep-breaker", func(error) bool { return false }, func() error {
+		return failErr
+	})
+
+	err := m.Do(context.Background(), "ep-breaker", func(error) bool { return false }, func() error {
+		t.Fatal("fn should not be called while the breaker is open")
+		return nil
+	})
+	if !errors.Is(err, ErrBackendUnavailable) {
+		t.Fatalf("expected ErrBackendUnavailable once the breaker has tripped, got %v", err)
+	}
+}
+
+func TestIsRetryableStatus(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "503 is retryable", err: newHTTPStatusError(503, errors.New("x")), want: true},
+		{name: "429 is retryable", err: newHTTPStatusError(429, errors.New("x")), want: true},
+		{name: "404 is not retryable", err: newHTTPStatusError(404, errors.New("x")), want: false},
+		{name: "plain error is not retryable", err: errors.New("x"), want: false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := isRetryableStatus(tc.err); got != tc.want {
+				t.Errorf("isRetryableStatus(%v) = %v, want %v", tc.err, got, tc.want)
+			}
+		})
+	}
+}
@@ -18,6 +18,7 @@ package service
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	backend "envhub/models"
 	"fmt"
@@ -30,49 +31,114 @@ import (
 	"api-service/models"
 )
 
-// ScheduleClient is a client for Schedule service
+// ScheduleClient is a client for Schedule service. Requests are bounded by a
+// token bucket (qps/burst, mirroring the k8s clientset config in
+// controller/cmd/main.go's SetUpController) and retried through a
+// RetryManager that backs off idempotent verbs and trips a per-endpoint
+// circuit breaker once the aenvhub server looks unhealthy.
 type ScheduleClient struct {
 	baseURL    string
 	httpClient *http.Client
+	retry      *RetryManager
 }
 
-// NewScheduleClient creates a new Schedule client
-func NewScheduleClient(baseURL string) *ScheduleClient {
+// NewScheduleClient creates a new Schedule client. qps/burst size the shared
+// token bucket; pass the same values used for the --qps/--burst flags.
+func NewScheduleClient(baseURL string, qps float64, burst int) *ScheduleClient {
 	return &ScheduleClient{
 		baseURL: baseURL,
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		retry: NewRetryManager(DefaultRetryPolicy, NewTokenBucket(qps, burst)),
 	}
 }
 
-// CreatePod creates a Pod
-func (c *ScheduleClient) CreatePod(req *backend.Env) (*models.EnvInstance, error) {
-	url := fmt.Sprintf("%s/pods", c.baseURL)
+func init() {
+	RegisterBackend("k8s", newScheduleClientBackend)
+}
 
-	jsonData, err := req.ToJSON()
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %v", err)
+// newScheduleClientBackend is the "k8s" BackendFactory, kept under its
+// original flag value for backward compatibility even though ScheduleClient
+// itself talks to an intermediary schedule service rather than the
+// apiserver directly; see KubernetesBackend for the direct client-go path.
+func newScheduleClientBackend(cfg map[string]interface{}) (EnvInstanceService, error) {
+	baseURL, _ := cfg["baseURL"].(string)
+	qps := 20.0
+	if v, ok := cfg["qps"].(float64); ok {
+		qps = v
 	}
-	httpReq, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %v", err)
+	burst := 40
+	if v, ok := cfg["burst"].(int); ok {
+		burst = v
 	}
-	httpReq.Header.Set("Content-Type", "application/json")
+	return NewScheduleClient(baseURL, qps, burst), nil
+}
 
-	resp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %v", err)
+// send issues an HTTP request, retrying through c.retry according to
+// retryable, and returns the response body once the status matches one of
+// okStatuses (defaulting to 200 OK).
+func (c *ScheduleClient) send(ctx context.Context, endpoint, method, url string, bodyBytes []byte, retryable func(error) bool, okStatuses ...int) ([]byte, error) {
+	if len(okStatuses) == 0 {
+		okStatuses = []int{http.StatusOK}
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	var respBody []byte
+	err := c.retry.Do(ctx, endpoint, retryable, func() error {
+		var reqBody io.Reader
+		if bodyBytes != nil {
+			reqBody = bytes.NewBuffer(bodyBytes)
+		}
+		httpReq, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+		if err != nil {
+			return &FatalError{Err: fmt.Errorf("failed to create request: %v", err)}
+		}
+		if bodyBytes != nil {
+			httpReq.Header.Set("Content-Type", "application/json")
+		}
+
+		resp, err := c.httpClient.Do(httpReq)
+		if err != nil {
+			return fmt.Errorf("failed to send request: %w", err)
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("failed to read response body: %v", err)
+		}
+
+		ok := false
+		for _, s := range okStatuses {
+			if resp.StatusCode == s {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return newHTTPStatusError(resp.StatusCode, fmt.Errorf("request failed with status: %d, body: %s", resp.StatusCode, string(body)))
+		}
+
+		respBody = body
+		return nil
+	})
+	return respBody, err
+}
+
+// CreatePod creates a Pod. POST only retries on failures that happened
+// before the request body reached the server (connect errors) or on a 5xx,
+// never on an ambiguous mid-write failure.
+func (c *ScheduleClient) CreatePod(ctx context.Context, req *backend.Env) (*models.EnvInstance, error) {
+	url := fmt.Sprintf("%s/pods", c.baseURL)
+
+	jsonData, err := req.ToJSON()
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %v", err)
+		return nil, fmt.Errorf("failed to marshal request: %v", err)
 	}
 
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return nil, fmt.Errorf("request failed with status: %d, body: %s", resp.StatusCode, string(body))
+	body, err := c.send(ctx, "create_pod", "POST", url, jsonData, retryablePostBeforeSend, http.StatusOK, http.StatusCreated)
+	if err != nil {
+		return nil, err
 	}
 
 	var createResp models.ClientResponse[models.EnvInstance]
@@ -88,27 +154,12 @@ func (c *ScheduleClient) CreatePod(req *backend.Env) (*models.EnvInstance, error
 }
 
 // GetPod queries a Pod
-func (c *ScheduleClient) GetPod(podName string) (*models.EnvInstance, error) {
+func (c *ScheduleClient) GetPod(ctx context.Context, podName string) (*models.EnvInstance, error) {
 	url := fmt.Sprintf("%s/pods/%s", c.baseURL, podName)
 
-	httpReq, err := http.NewRequest("GET", url, nil)
+	body, err := c.send(ctx, "get_pod", "GET", url, nil, retryableIdempotent)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %v", err)
-	}
-
-	resp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %v", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %v", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("request failed with status: %d, body: %s", resp.StatusCode, string(body))
+		return nil, err
 	}
 
 	var getResp models.ClientResponse[models.EnvInstance]
@@ -123,29 +174,18 @@ func (c *ScheduleClient) GetPod(podName string) (*models.EnvInstance, error) {
 	return &getResp.Data, nil
 }
 
-// DeletePod deletes a Pod
-func (c *ScheduleClient) DeletePod(podName string) (bool, error) {
+// DeletePod deletes a Pod. When the backing Env has a multi-resource
+// rollout (Env.Spec.Resources), the aenvhub server's own reconciler
+// finalizer tears those resources down in reverse order before the pod
+// disappears, so Cleanup never needs to know about the rollout itself.
+func (c *ScheduleClient) DeletePod(ctx context.Context, podName string) (bool, error) {
 	url := fmt.Sprintf("%s/pods/%s", c.baseURL, podName)
 
-	httpReq, err := http.NewRequest("DELETE", url, nil)
+	body, err := c.send(ctx, "delete_pod", "DELETE", url, nil, retryableIdempotent)
 	if err != nil {
-		return false, fmt.Errorf("failed to create request: %v", err)
+		return false, err
 	}
 
-	resp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return false, fmt.Errorf("failed to send request: %v", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return false, fmt.Errorf("failed to read response body: %v", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return false, fmt.Errorf("request failed with status: %d, body: %s", resp.StatusCode, string(body))
-	}
 	var deleteResp models.ClientResponse[bool]
 	if err := json.Unmarshal(body, &deleteResp); err != nil {
 		return false, fmt.Errorf("failed to unmarshal response: %v", err)
@@ -159,27 +199,12 @@ func (c *ScheduleClient) DeletePod(podName string) (bool, error) {
 }
 
 // FilterPod filter pods by condition
-func (c *ScheduleClient) FilterPods() (*[]models.EnvInstance, error) {
+func (c *ScheduleClient) FilterPods(ctx context.Context) (*[]models.EnvInstance, error) {
 	url := fmt.Sprintf("%s/pods?filter=expired", c.baseURL)
 
-	httpReq, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %v", err)
-	}
-
-	resp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %v", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
+	body, err := c.send(ctx, "filter_pods", "GET", url, nil, retryableIdempotent)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %v", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("request failed with status: %d, body: %s", resp.StatusCode, string(body))
+		return nil, err
 	}
 
 	var getResp models.ClientResponse[[]models.EnvInstance]
@@ -201,18 +226,18 @@ func (c *ScheduleClient) FilterPods() (*[]models.EnvInstance, error) {
 */
 
 // CreateEnvInstance implements EnvInstanceService interface - delegate to CreatePod
-func (c *ScheduleClient) CreateEnvInstance(req *backend.Env) (*models.EnvInstance, error) {
-	return c.CreatePod(req)
+func (c *ScheduleClient) CreateEnvInstance(ctx context.Context, req *backend.Env) (*models.EnvInstance, error) {
+	return c.CreatePod(ctx, req)
 }
 
 // GetEnvInstance implements EnvInstanceService interface - delegate to GetPod
-func (c *ScheduleClient) GetEnvInstance(id string) (*models.EnvInstance, error) {
-	return c.GetPod(id)
+func (c *ScheduleClient) GetEnvInstance(ctx context.Context, id string) (*models.EnvInstance, error) {
+	return c.GetPod(ctx, id)
 }
 
 // DeleteEnvInstance implements EnvInstanceService interface - delegate to DeletePod
-func (c *ScheduleClient) DeleteEnvInstance(id string) error {
-	success, err := c.DeletePod(id)
+func (c *ScheduleClient) DeleteEnvInstance(ctx context.Context, id string) error {
+	success, err := c.DeletePod(ctx, id)
 	if err != nil {
 		return err
 	}
@@ -223,18 +248,42 @@ func (c *ScheduleClient) DeleteEnvInstance(id string) error {
 }
 
 // ListEnvInstances implements EnvInstanceService interface - not implemented yet
-func (c *ScheduleClient) ListEnvInstances(envName string) ([]*models.EnvInstance, error) {
+func (c *ScheduleClient) ListEnvInstances(ctx context.Context, envName string) ([]*models.EnvInstance, error) {
 	return nil, fmt.Errorf("ListEnvInstances is not implemented")
 }
 
-func (c *ScheduleClient) Warmup(req *backend.Env) error {
+func (c *ScheduleClient) Warmup(ctx context.Context, req *backend.Env) error {
 	return fmt.Errorf("warmup is not implemented")
 }
 
-func (c *ScheduleClient) Cleanup() error {
+// StreamLogs implements EnvInstanceService interface - not implemented yet,
+// the schedule service has no logs endpoint to proxy.
+func (c *ScheduleClient) StreamLogs(ctx context.Context, id string, opts LogOptions) (io.ReadCloser, error) {
+	return nil, fmt.Errorf("StreamLogs is not implemented")
+}
+
+// Exec implements EnvInstanceService interface - not implemented yet, the
+// schedule service has no exec endpoint to proxy.
+func (c *ScheduleClient) Exec(ctx context.Context, id string, cmd []string, stdin io.Reader) (ExecStream, error) {
+	return nil, fmt.Errorf("Exec is not implemented")
+}
+
+// BatchDeleteEnvInstances implements EnvInstanceService interface, fanning
+// out to DeleteEnvInstance through a bounded worker pool.
+func (c *ScheduleClient) BatchDeleteEnvInstances(ctx context.Context, ids []string, concurrency int) (map[string]error, error) {
+	return batchDeleteEnvInstances(ctx, ids, concurrency, c.DeleteEnvInstance)
+}
+
+// BatchCreateEnvInstances implements EnvInstanceService interface, fanning
+// out to CreateEnvInstance through a bounded worker pool.
+func (c *ScheduleClient) BatchCreateEnvInstances(ctx context.Context, reqs []*backend.Env, concurrency int) (map[string]*models.EnvInstance, map[string]error, error) {
+	return batchCreateEnvInstances(ctx, reqs, concurrency, c.CreateEnvInstance)
+}
+
+func (c *ScheduleClient) Cleanup(ctx context.Context) error {
 	log.Infof("Starting cleanup task...")
 	// get all EnvInstance
-	envInstances, err := c.FilterPods()
+	envInstances, err := c.FilterPods(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get env instances: %v", err)
 	}
@@ -250,7 +299,7 @@ func (c *ScheduleClient) Cleanup() error {
 		if instance.Status == "Terminated" {
 			continue
 		}
-		deleted, err := c.DeletePod(instance.ID)
+		deleted, err := c.DeletePod(ctx, instance.ID)
 		if err != nil {
 			log.Warnf("Failed to delete instance %s: %v", instance.ID, err)
 			continue
@@ -0,0 +1,451 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"api-service/models"
+	backend "envhub/models"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+// WarmPoolPolicy bounds how many pre-warmed instances WarmPoolManager keeps
+// ready for a single env, and how long it lets them sit around.
+type WarmPoolPolicy struct {
+	MinReady            int
+	MaxReady            int
+	MaxAgeBeforeRecycle time.Duration
+	IdleTTL             time.Duration
+}
+
+// defaultWarmPoolPolicy applies to any env that doesn't set the
+// warmPool* keys below in its DeployConfig.
+var defaultWarmPoolPolicy = WarmPoolPolicy{
+	MinReady:            1,
+	MaxReady:            5,
+	MaxAgeBeforeRecycle: 24 * time.Hour,
+	IdleTTL:             30 * time.Minute,
+}
+
+// policyFromDeployConfig reads per-env overrides out of env.DeployConfig,
+// the same untyped map CreateEnvInstance already threads "secondImageName",
+// "environmentVariables", "arguments" and "ttl" through.
+func policyFromDeployConfig(env *backend.Env) WarmPoolPolicy {
+	policy := defaultWarmPoolPolicy
+	if env == nil || env.DeployConfig == nil {
+		return policy
+	}
+	if v, ok := env.DeployConfig["warmPoolMinReady"].(float64); ok {
+		policy.MinReady = int(v)
+	}
+	if v, ok := env.DeployConfig["warmPoolMaxReady"].(float64); ok {
+		policy.MaxReady = int(v)
+	}
+	if v, ok := env.DeployConfig["warmPoolMaxAgeBeforeRecycle"].(string); ok {
+		if d, err := time.ParseDuration(v); err == nil {
+			policy.MaxAgeBeforeRecycle = d
+		}
+	}
+	if v, ok := env.DeployConfig["warmPoolIdleTTL"].(string); ok {
+		if d, err := time.ParseDuration(v); err == nil {
+			policy.IdleTTL = d
+		}
+	}
+	return policy
+}
+
+// envPool is the in-memory state WarmPoolManager tracks for one env: the
+// template used to pre-warm new instances, the policy governing it, the
+// ready instances currently sitting idle, and the instances Acquire has
+// already handed out to a caller.
+type envPool struct {
+	template *backend.Env
+	policy   WarmPoolPolicy
+	ready    []*models.EnvInstance
+	// claimed holds the IDs of instances Acquire has handed to a caller
+	// (whether from the ready pool or freshly created on a miss), keyed by
+	// instance ID. reconcileEnv/cleanupEnv must never recycle, trim, or
+	// reintroduce a claimed instance into ready - that would hand the same
+	// backing instance to a second caller, or delete it out from under the
+	// first.
+	claimed map[string]struct{}
+}
+
+// WarmPoolManager sits above an EnvInstanceService and maintains a pool of
+// pre-warmed instances per env, so Acquire can hand one out immediately
+// instead of the caller waiting on a synchronous CreateEnvInstance.
+type WarmPoolManager struct {
+	service EnvInstanceService
+
+	mu    sync.Mutex
+	pools map[string]*envPool
+
+	interval time.Duration
+	cancel   context.CancelFunc
+}
+
+// NewWarmPoolManager builds a WarmPoolManager that reconciles every interval
+// once Start is called.
+func NewWarmPoolManager(svc EnvInstanceService, interval time.Duration) *WarmPoolManager {
+	return &WarmPoolManager{
+		service:  svc,
+		pools:    make(map[string]*envPool),
+		interval: interval,
+	}
+}
+
+// Track registers env with the pool, refreshing its policy and warm-instance
+// template. The controller calls this from CreateEnvInstance and Warmup,
+// since both handlers already resolve the backend.Env the pool needs in
+// order to pre-warm more instances later.
+func (m *WarmPoolManager) Track(env *backend.Env) {
+	if env == nil || env.Name == "" {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	pool, ok := m.pools[env.Name]
+	if !ok {
+		pool = &envPool{}
+		m.pools[env.Name] = pool
+	}
+	pool.template = env
+	pool.policy = policyFromDeployConfig(env)
+}
+
+// BumpMinReady raises (or lowers, for negative delta) the tracked pool's
+// MinReady, floored at zero. This backs the Warmup handler, which becomes a
+// thin wrapper around this call instead of firing a single synchronous
+// create.
+func (m *WarmPoolManager) BumpMinReady(envName string, delta int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	pool, ok := m.pools[envName]
+	if !ok {
+		return fmt.Errorf("warm pool: env %s is not tracked yet", envName)
+	}
+	pool.policy.MinReady += delta
+	if pool.policy.MinReady < 0 {
+		pool.policy.MinReady = 0
+	}
+	return nil
+}
+
+// Acquire hands out a ready instance for envName, falling back to a
+// synchronous CreateEnvInstance against the pool's template on a miss. env
+// must have been Track'd at least once (normally by the caller itself,
+// immediately before Acquire) so the fallback has something to create from.
+// Either way, the returned instance is claimed (see envPool.claimed) before
+// Acquire returns, so the background reconciler won't treat it as available
+// to recycle, trim, or hand out again.
+func (m *WarmPoolManager) Acquire(envName string) (*models.EnvInstance, error) {
+	start := time.Now()
+
+	m.mu.Lock()
+	pool, ok := m.pools[envName]
+	if !ok {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("warm pool: env %s is not tracked yet", envName)
+	}
+	var instance *models.EnvInstance
+	if len(pool.ready) > 0 {
+		instance = pool.ready[0]
+		pool.ready = pool.ready[1:]
+	}
+	template := pool.template
+	readyLeft := len(pool.ready)
+	m.mu.Unlock()
+
+	if instance != nil {
+		m.claim(envName, instance.ID)
+		warmPoolAcquireTotal.WithLabelValues(envName, "hit").Inc()
+		warmPoolSize.WithLabelValues(envName).Set(float64(readyLeft))
+		warmPoolAcquireLatencySeconds.WithLabelValues(envName).Observe(time.Since(start).Seconds())
+		return instance, nil
+	}
+
+	warmPoolAcquireTotal.WithLabelValues(envName, "miss").Inc()
+	instance, err := m.service.CreateEnvInstance(context.Background(), template)
+	warmPoolAcquireLatencySeconds.WithLabelValues(envName).Observe(time.Since(start).Seconds())
+	if err == nil && instance != nil {
+		m.claim(envName, instance.ID)
+	}
+	return instance, err
+}
+
+// claim marks instanceID as handed out to a caller for envName, so
+// reconcileEnv excludes it from its candidate list. A no-op if envName isn't
+// tracked or instanceID is empty (e.g. a backend that doesn't assign one).
+func (m *WarmPoolManager) claim(envName, instanceID string) {
+	if instanceID == "" {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	pool, ok := m.pools[envName]
+	if !ok {
+		return
+	}
+	if pool.claimed == nil {
+		pool.claimed = make(map[string]struct{})
+	}
+	pool.claimed[instanceID] = struct{}{}
+}
+
+// Release clears instanceID from whichever pool's claimed set holds it, so a
+// caller that's done with (or just deleted) an instance Acquire handed out
+// lets the reconciler account for it normally again. Checks every tracked
+// pool rather than requiring the caller to know which env an instance ID
+// belongs to, mirroring trackedEnvNames' all-pools scan. Safe to call for an
+// instance Acquire never claimed; it's a no-op.
+func (m *WarmPoolManager) Release(instanceID string) {
+	if instanceID == "" {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, pool := range m.pools {
+		delete(pool.claimed, instanceID)
+	}
+}
+
+// Start launches the background reconciler that converges every tracked
+// pool toward its policy's MinReady/MaxReady, recycling instances older than
+// MaxAgeBeforeRecycle along the way. Mirrors AEnvCleanManager's
+// ticker+context shape in cleanup_service.go.
+func (m *WarmPoolManager) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+
+	ticker := time.NewTicker(m.interval)
+	go func() {
+		defer ticker.Stop()
+		m.reconcileAll()
+		for {
+			select {
+			case <-ticker.C:
+				m.reconcileAll()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the background reconciler.
+func (m *WarmPoolManager) Stop() {
+	if m.cancel != nil {
+		m.cancel()
+	}
+}
+
+func (m *WarmPoolManager) trackedEnvNames() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	names := make([]string, 0, len(m.pools))
+	for name := range m.pools {
+		names = append(names, name)
+	}
+	return names
+}
+
+func (m *WarmPoolManager) reconcileAll() {
+	for _, name := range m.trackedEnvNames() {
+		if err := m.reconcileEnv(name); err != nil {
+			log.Warnf("warm pool: failed to reconcile env %s: %v", name, err)
+		}
+	}
+}
+
+// reconcileEnv lists the env's live instances, recycles anything past
+// MaxAgeBeforeRecycle, trims down to MaxReady, then pre-warms up to
+// MinReady. Instances Acquire has already claimed for a caller are excluded
+// from every step above - they're in active use, not pool inventory - and
+// the claimed set itself is refreshed to only the claimed IDs still present
+// in this listing, so an instance deleted through some other path (e.g. the
+// owning caller tearing it down directly) doesn't leak in the set forever.
+func (m *WarmPoolManager) reconcileEnv(envName string) error {
+	m.mu.Lock()
+	pool, ok := m.pools[envName]
+	if !ok {
+		m.mu.Unlock()
+		return nil
+	}
+	policy := pool.policy
+	template := pool.template
+	claimed := make(map[string]struct{}, len(pool.claimed))
+	for id := range pool.claimed {
+		claimed[id] = struct{}{}
+	}
+	m.mu.Unlock()
+
+	instances, err := m.service.ListEnvInstances(context.Background(), envName)
+	if err != nil {
+		return fmt.Errorf("failed to list instances: %v", err)
+	}
+
+	now := time.Now()
+	liveClaimed := make(map[string]struct{}, len(claimed))
+	ready := instances[:0:0]
+	for _, inst := range instances {
+		if _, ok := claimed[inst.ID]; ok {
+			liveClaimed[inst.ID] = struct{}{}
+			continue
+		}
+		if instanceExpired(inst, policy.MaxAgeBeforeRecycle, now) {
+			if err := m.service.DeleteEnvInstance(context.Background(), inst.ID); err != nil {
+				log.Warnf("warm pool: failed to recycle aged instance %s (env %s): %v", inst.ID, envName, err)
+				ready = append(ready, inst)
+			}
+			continue
+		}
+		ready = append(ready, inst)
+	}
+
+	for len(ready) > policy.MaxReady {
+		victim := ready[len(ready)-1]
+		ready = ready[:len(ready)-1]
+		if err := m.service.DeleteEnvInstance(context.Background(), victim.ID); err != nil {
+			log.Warnf("warm pool: failed to trim instance %s (env %s): %v", victim.ID, envName, err)
+		}
+	}
+
+	for len(ready) < policy.MinReady {
+		if template == nil {
+			break
+		}
+		created, err := m.service.CreateEnvInstance(context.Background(), template)
+		if err != nil {
+			log.Warnf("warm pool: failed to pre-warm instance for env %s: %v", envName, err)
+			break
+		}
+		ready = append(ready, created)
+	}
+
+	m.mu.Lock()
+	pool.ready = ready
+	pool.claimed = liveClaimed
+	m.mu.Unlock()
+	warmPoolSize.WithLabelValues(envName).Set(float64(len(ready)))
+	return nil
+}
+
+// Cleanup drains only the instances that have sat idle past their env's
+// IdleTTL, replacing the old service-wide EnvInstanceService.Cleanup reset.
+// It implements the unexported AEnvCleaner interface from cleanup_service.go
+// so it can be handed straight to NewAEnvCleanManager.
+func (m *WarmPoolManager) Cleanup() error {
+	var firstErr error
+	for _, name := range m.trackedEnvNames() {
+		if err := m.cleanupEnv(name); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (m *WarmPoolManager) cleanup() {
+	if err := m.Cleanup(); err != nil {
+		log.Warnf("warm pool: cleanup pass failed: %v", err)
+	}
+}
+
+func (m *WarmPoolManager) cleanupEnv(envName string) error {
+	m.mu.Lock()
+	pool, ok := m.pools[envName]
+	if !ok {
+		m.mu.Unlock()
+		return nil
+	}
+	idleTTL := pool.policy.IdleTTL
+	ready := pool.ready
+	m.mu.Unlock()
+
+	if idleTTL <= 0 {
+		return nil
+	}
+
+	now := time.Now()
+	kept := ready[:0:0]
+	for _, inst := range ready {
+		if instanceExpired(inst, idleTTL, now) {
+			if err := m.service.DeleteEnvInstance(context.Background(), inst.ID); err != nil {
+				return fmt.Errorf("failed to drain idle instance %s: %v", inst.ID, err)
+			}
+			continue
+		}
+		kept = append(kept, inst)
+	}
+
+	m.mu.Lock()
+	pool.ready = kept
+	m.mu.Unlock()
+	warmPoolSize.WithLabelValues(envName).Set(float64(len(kept)))
+	return nil
+}
+
+// instanceExpired reports whether inst was created more than ttl ago. An
+// unparseable or empty CreatedAt is treated as not expired rather than
+// risking an eager delete on a formatting mismatch between backends.
+func instanceExpired(inst *models.EnvInstance, ttl time.Duration, now time.Time) bool {
+	if ttl <= 0 || inst.CreatedAt == "" {
+		return false
+	}
+	created, err := time.Parse(time.RFC3339, inst.CreatedAt)
+	if err != nil {
+		return false
+	}
+	return now.Sub(created) > ttl
+}
+
+var (
+	warmPoolAcquireTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: resilienceMetricsNamespace,
+			Name:      "warm_pool_acquire_total",
+			Help:      "Total WarmPoolManager Acquire calls by env and outcome (hit/miss).",
+		},
+		[]string{"env", "outcome"},
+	)
+	warmPoolSize = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: resilienceMetricsNamespace,
+			Name:      "warm_pool_size",
+			Help:      "Current number of ready pre-warmed instances per env.",
+		},
+		[]string{"env"},
+	)
+	warmPoolAcquireLatencySeconds = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: resilienceMetricsNamespace,
+			Name:      "warm_pool_acquire_latency_seconds",
+			Help:      "Acquire latency by env, covering both pool hits and cold-create misses.",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"env"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(warmPoolAcquireTotal, warmPoolSize, warmPoolAcquireLatencySeconds)
+}
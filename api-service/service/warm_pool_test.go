@@ -0,0 +1,267 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+
+	"api-service/models"
+	backend "envhub/models"
+)
+
+// fakeEnvInstanceService is a minimal in-memory EnvInstanceService double
+// that lets warm_pool tests control exactly which instances ListEnvInstances
+// returns and observe which ones get deleted, without a real backend.
+type fakeEnvInstanceService struct {
+	mu        sync.Mutex
+	instances map[string]*models.EnvInstance
+	deleted   map[string]int
+	nextID    int
+}
+
+func newFakeEnvInstanceService() *fakeEnvInstanceService {
+	return &fakeEnvInstanceService{
+		instances: make(map[string]*models.EnvInstance),
+		deleted:   make(map[string]int),
+	}
+}
+
+func (f *fakeEnvInstanceService) seed(inst *models.EnvInstance) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.instances[inst.ID] = inst
+}
+
+func (f *fakeEnvInstanceService) GetEnvInstance(ctx context.Context, id string) (*models.EnvInstance, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.instances[id], nil
+}
+
+func (f *fakeEnvInstanceService) CreateEnvInstance(ctx context.Context, req *backend.Env) (*models.EnvInstance, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextID++
+	inst := &models.EnvInstance{ID: fmt.Sprintf("created-%d", f.nextID)}
+	f.instances[inst.ID] = inst
+	return inst, nil
+}
+
+func (f *fakeEnvInstanceService) DeleteEnvInstance(ctx context.Context, id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.instances, id)
+	f.deleted[id]++
+	return nil
+}
+
+func (f *fakeEnvInstanceService) ListEnvInstances(ctx context.Context, envName string) ([]*models.EnvInstance, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make([]*models.EnvInstance, 0, len(f.instances))
+	for _, inst := range f.instances {
+		out = append(out, inst)
+	}
+	return out, nil
+}
+
+func (f *fakeEnvInstanceService) Warmup(ctx context.Context, req *backend.Env) error { return nil }
+func (f *fakeEnvInstanceService) Cleanup(ctx context.Context) error                  { return nil }
+
+func (f *fakeEnvInstanceService) StreamLogs(ctx context.Context, id string, opts LogOptions) (io.ReadCloser, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (f *fakeEnvInstanceService) Exec(ctx context.Context, id string, cmd []string, stdin io.Reader) (ExecStream, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (f *fakeEnvInstanceService) BatchDeleteEnvInstances(ctx context.Context, ids []string, concurrency int) (map[string]error, error) {
+	results := make(map[string]error, len(ids))
+	for _, id := range ids {
+		results[id] = f.DeleteEnvInstance(ctx, id)
+	}
+	return results, nil
+}
+
+func (f *fakeEnvInstanceService) BatchCreateEnvInstances(ctx context.Context, reqs []*backend.Env, concurrency int) (map[string]*models.EnvInstance, map[string]error, error) {
+	return nil, nil, fmt.Errorf("not implemented")
+}
+
+func (f *fakeEnvInstanceService) deletedCount(id string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.deleted[id]
+}
+
+func TestWarmPoolManager_Acquire_HitClaimsInstance(t *testing.T) {
+	svc := newFakeEnvInstanceService()
+	m := NewWarmPoolManager(svc, 0)
+	m.Track(&backend.Env{Name: "env1"})
+
+	ready := &models.EnvInstance{ID: "ready-1"}
+	svc.seed(ready)
+	m.pools["env1"].ready = []*models.EnvInstance{ready}
+
+	got, err := m.Acquire("env1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.ID != "ready-1" {
+		t.Fatalf("expected the ready instance to be handed out, got %v", got.ID)
+	}
+
+	pool := m.pools["env1"]
+	if _, claimed := pool.claimed["ready-1"]; !claimed {
+		t.Fatal("expected the handed-out instance to be marked claimed")
+	}
+	if len(pool.ready) != 0 {
+		t.Fatalf("expected the ready pool to be drained, got %d left", len(pool.ready))
+	}
+}
+
+func TestWarmPoolManager_Acquire_MissClaimsCreatedInstance(t *testing.T) {
+	svc := newFakeEnvInstanceService()
+	m := NewWarmPoolManager(svc, 0)
+	m.Track(&backend.Env{Name: "env1"})
+
+	got, err := m.Acquire("env1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	pool := m.pools["env1"]
+	if _, claimed := pool.claimed[got.ID]; !claimed {
+		t.Fatal("expected the synchronously created instance to be marked claimed too")
+	}
+}
+
+func TestWarmPoolManager_ReconcileEnv_NeverRecyclesClaimedInstance(t *testing.T) {
+	svc := newFakeEnvInstanceService()
+	m := NewWarmPoolManager(svc, 0)
+	m.Track(&backend.Env{Name: "env1"})
+
+	claimed := &models.EnvInstance{ID: "claimed-1"}
+	svc.seed(claimed)
+	m.pools["env1"].ready = []*models.EnvInstance{claimed}
+	if _, err := m.Acquire("env1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	pool := m.pools["env1"]
+	pool.policy.MaxReady = 0 // would trim everything if claimed were treated as pool inventory
+	pool.policy.MinReady = 0
+
+	if err := m.reconcileEnv("env1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if svc.deletedCount("claimed-1") != 0 {
+		t.Fatal("expected reconcileEnv to never delete a claimed instance")
+	}
+	for _, inst := range pool.ready {
+		if inst.ID == "claimed-1" {
+			t.Fatal("expected reconcileEnv to never reintroduce a claimed instance into the ready pool")
+		}
+	}
+	if _, stillClaimed := pool.claimed["claimed-1"]; !stillClaimed {
+		t.Fatal("expected the claim to survive reconcile while the instance is still live")
+	}
+}
+
+func TestWarmPoolManager_ReconcileEnv_TrimsOnlyUnclaimedInstances(t *testing.T) {
+	svc := newFakeEnvInstanceService()
+	m := NewWarmPoolManager(svc, 0)
+	m.Track(&backend.Env{Name: "env1"})
+
+	claimed := &models.EnvInstance{ID: "claimed-1"}
+	unclaimed := &models.EnvInstance{ID: "unclaimed-1"}
+	svc.seed(claimed)
+	svc.seed(unclaimed)
+	m.pools["env1"].ready = []*models.EnvInstance{claimed}
+	if _, err := m.Acquire("env1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	pool := m.pools["env1"]
+	pool.ready = []*models.EnvInstance{unclaimed}
+	pool.policy.MaxReady = 0
+	pool.policy.MinReady = 0
+
+	if err := m.reconcileEnv("env1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if svc.deletedCount("claimed-1") != 0 {
+		t.Fatal("expected the claimed instance to survive trimming")
+	}
+	if svc.deletedCount("unclaimed-1") != 1 {
+		t.Fatal("expected the unclaimed instance to be trimmed since MaxReady is 0")
+	}
+}
+
+func TestWarmPoolManager_Release_UnclaimsInstance(t *testing.T) {
+	svc := newFakeEnvInstanceService()
+	m := NewWarmPoolManager(svc, 0)
+	m.Track(&backend.Env{Name: "env1"})
+
+	claimed := &models.EnvInstance{ID: "claimed-1"}
+	svc.seed(claimed)
+	m.pools["env1"].ready = []*models.EnvInstance{claimed}
+	if _, err := m.Acquire("env1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	m.Release("claimed-1")
+
+	pool := m.pools["env1"]
+	if _, stillClaimed := pool.claimed["claimed-1"]; stillClaimed {
+		t.Fatal("expected Release to clear the claim")
+	}
+}
+
+func TestWarmPoolManager_ReconcileEnv_GarbageCollectsDeletedClaim(t *testing.T) {
+	svc := newFakeEnvInstanceService()
+	m := NewWarmPoolManager(svc, 0)
+	m.Track(&backend.Env{Name: "env1"})
+
+	claimed := &models.EnvInstance{ID: "claimed-1"}
+	svc.seed(claimed)
+	m.pools["env1"].ready = []*models.EnvInstance{claimed}
+	if _, err := m.Acquire("env1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Simulate the claimed instance having been deleted through some other
+	// path (e.g. DeleteEnvInstance) without Release ever being called.
+	if err := svc.DeleteEnvInstance(context.Background(), "claimed-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := m.reconcileEnv("env1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	pool := m.pools["env1"]
+	if _, stillClaimed := pool.claimed["claimed-1"]; stillClaimed {
+		t.Fatal("expected reconcileEnv to drop a claim for an instance no longer returned by ListEnvInstances")
+	}
+}
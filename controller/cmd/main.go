@@ -17,6 +17,8 @@ limitations under the License.
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"net/http"
@@ -26,9 +28,18 @@ import (
 	"time"
 
 	aenvhubserver "controller/pkg/aenvhub_http_server"
-
+	aenvv1alpha1 "controller/pkg/apis/aenv/v1alpha1"
+	"controller/pkg/cluster"
+	aenvcontroller "controller/pkg/controller"
+	"controller/pkg/healthz"
+	"controller/pkg/metrics"
+	"controller/pkg/parallel"
+	"controller/pkg/storage"
+
+	"k8s.io/client-go/discovery"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/manager/signals"
@@ -42,37 +53,119 @@ var (
 	defaultNamespace string
 	logDir           string
 	serverPort       int
+	clustersConfig   string
+	reapInterval     time.Duration
+	reapGracePeriod  int64
+	maxParallelOps   int
+	cacheResync      time.Duration
+
+	// Promoted from SetUpController's local scope so AddReadiness's
+	// /configz endpoint can report the effective configuration.
+	metricsAddr                                                   string
+	pprofAddr                                                     string
+	qps                                                           int
+	burst                                                         int
+	enableLeaderElection                                          bool
+	leaderDuration, leaderRenewDuration, leaderRetryPeriodDuation string
 
 	controllerManager manager.Manager
 )
 
+// safeGo runs fn in its own goroutine, recovering from panics so one
+// crashed component (health server, http server, leader-elected startup)
+// doesn't take the whole process down; panics are logged and counted.
+func safeGo(name string, fn func()) {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				metrics.RecordGoroutinePanic(name)
+				klog.Errorf("recovered from panic in goroutine %q: %v", name, r)
+			}
+		}()
+		fn()
+	}()
+}
+
 func main() {
 	klog.Infof("entering main for AEnv server")
 
 	flag.StringVar(&defaultNamespace, "namespace", "aenvsandbox", "The namespace that pods are using.")
 	flag.StringVar(&logDir, "logdir", "/home/admin/logs", "The dir of log output.")
 	flag.IntVar(&serverPort, "server-port", 8080, "The value for server port.")
+	flag.StringVar(&clustersConfig, "clusters-config", "", "Path to a JSON file describing member clusters for multi-cluster scheduling.")
+	flag.DurationVar(&reapInterval, "reap-interval", time.Minute, "How often the TTL reaper sweeps for expired pods.")
+	flag.Int64Var(&reapGracePeriod, "reap-grace-period", 30, "Grace period, in seconds, used when the TTL reaper deletes an expired pod.")
+	flag.IntVar(&maxParallelOps, "max-parallel-ops", 10, "Maximum number of pod create/delete calls the prewarm reconciler runs concurrently.")
+	flag.DurationVar(&cacheResync, "cache-resync-period", 5*time.Minute, "How often the pod/node/configmap informers do a full relist.")
 	klog.InitFlags(nil)
 
 	// SetUpController() -> AddReadiness() -> Provide StartHttpServer() service after leader election.
 	SetUpController()
 }
 
-func StartHttpServer() {
+// StartHttpServer brings up the AEnv pool server. envClient, when non-nil, is
+// used to persist Envs played in through POST /envs/play (see
+// aenvhub_http_server.AEnvPlayHandler) and to create AEnvInstance CRs from
+// POST /pods instead of Pods directly (see AEnvPodHandler.SetInstanceClient);
+// it is nil only if called without a controller-runtime client available.
+func StartHttpServer(envClient client.Client) {
 
 	klog.Infof("starting AENV http server...")
 
 	// AENV Pod Manager
-	aenvPodManager, err := aenvhubserver.NewAEnvPodHandler()
+	aenvPodManager, err := aenvhubserver.NewAEnvPodHandler(cacheResync)
 	if err != nil {
 		klog.Fatalf("failed to create AENV Pod manager, err is %v", err)
 	}
 
+	if clustersConfig != "" {
+		clusterStore, err := loadClusterStore(clustersConfig)
+		if err != nil {
+			klog.Fatalf("failed to load clusters config %s, err is %v", clustersConfig, err)
+		}
+		aenvPodManager.SetClusterStore(clusterStore)
+		clusterStore.StartHealthChecks(30*time.Second, make(chan struct{}))
+		klog.Infof("multi-cluster scheduling enabled with %d member clusters", clusterStore.Len())
+	}
+
+	aenvPodManager.SetReapConfig(reapInterval, reapGracePeriod)
+	aenvPodManager.StartReaper(make(chan struct{}))
+	aenvPodManager.StartRetryController(make(chan struct{}))
+	// No SetTTLCleanupHook call here yet: this binary has no Redis/backend
+	// client wired in, so the TTL GC controller runs its default
+	// noopTTLCleanupHook, which logs instead of performing real downstream
+	// cleanup. Wiring a real hook in is a follow-up.
+	aenvPodManager.StartTTLGC(make(chan struct{}))
+
+	if envClient != nil {
+		aenvPodManager.SetInstanceClient(envClient)
+	}
+
+	pool := parallel.NewPool(maxParallelOps)
+	pool.Start(make(chan struct{}))
+	aenvPodManager.SetPrewarmPool(pool)
+	aenvPodManager.StartPrewarmReconciler(make(chan struct{}))
+
+	aenvExecManager := aenvhubserver.NewAEnvExecHandler(aenvPodManager)
+
+	var envStore storage.EnvStorage
+	if envClient != nil {
+		envStore = storage.NewCRDEnvStorage(envClient, defaultNamespace)
+		aenvPodManager.SetEnvStore(envStore)
+	}
+	aenvPlayManager := aenvhubserver.NewAEnvPlayHandler(aenvPodManager, envStore)
+
 	// Set up routes
 	mux := http.NewServeMux()
 
 	mux.Handle("/pods", aenvPodManager)
 	mux.Handle("/pods/", aenvPodManager)
+	mux.Handle("/tasks/", aenvPodManager)
+	mux.Handle("/env-instance/manifest", aenvPodManager)
+	mux.Handle("/envInstances/", aenvExecManager)
+	mux.Handle("/exec/", aenvExecManager)
+	mux.Handle("/envs/play", aenvPlayManager)
+	mux.Handle("/envs/play/", aenvPlayManager)
 
 	// Start server
 	poolserver := &http.Server{
@@ -89,16 +182,44 @@ func StartHttpServer() {
 	}
 }
 
+// clusterConfigEntry is the on-disk shape of one --clusters-config entry.
+type clusterConfigEntry struct {
+	Name        string            `json:"name"`
+	Labels      map[string]string `json:"labels"`
+	Weight      int32             `json:"weight"`
+	Kubeconfig  string            `json:"kubeconfig"`
+	Server      string            `json:"server"`
+	BearerToken string            `json:"bearerToken"`
+	CAFile      string            `json:"caFile"`
+}
+
+// loadClusterStore reads --clusters-config and builds a ClusterStore out of it.
+func loadClusterStore(path string) (*cluster.ClusterStore, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read clusters config: %v", err)
+	}
+	var entries []clusterConfigEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse clusters config: %v", err)
+	}
+
+	store := cluster.NewClusterStore()
+	for _, e := range entries {
+		store.Register(&cluster.Cluster{
+			Name:        e.Name,
+			Labels:      e.Labels,
+			Weight:      e.Weight,
+			Kubeconfig:  e.Kubeconfig,
+			Server:      e.Server,
+			BearerToken: e.BearerToken,
+			CAFile:      e.CAFile,
+		})
+	}
+	return store, nil
+}
+
 func SetUpController() {
-	var (
-		metricsAddr string
-		pprofAddr   string
-		qps         int
-		burst       int
-
-		enableLeaderElection                                          bool
-		leaderDuration, leaderRenewDuration, leaderRetryPeriodDuation string
-	)
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8088", "The address the metric endpoint binds to.")
 	flag.StringVar(&pprofAddr, "pprof-addr", ":8089", "The address the pprof endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", true, "Enable leader election")
@@ -171,6 +292,10 @@ func SetUpController() {
 		klog.Errorf("unable add APIs to scheme, err is %v", err)
 		os.Exit(1)
 	}
+	if err = aenvv1alpha1.AddToScheme(controllerManager.GetScheme()); err != nil {
+		klog.Errorf("unable add aenv.io APIs to scheme, err is %v", err)
+		os.Exit(1)
+	}
 
 	// Setup all Controllers
 	klog.Infof("Setting up controller")
@@ -189,34 +314,72 @@ func SetUpController() {
 	}
 }
 
+// AddReadiness registers named health checks and serves them as /livez,
+// /readyz, /healthz (each supporting ?verbose=1 and ?exclude=a,b, plus
+// per-check /<name> sub-paths) and /configz, then starts StartHttpServer
+// once this instance is elected leader. The health server and the
+// leader-elected startup both run under safeGo so a panic in either is
+// recovered, logged and counted instead of taking the process down.
 func AddReadiness(mgr manager.Manager) {
-
 	// Record leader status
 	var isLeader atomic.Bool
+
+	checks := []healthz.Checker{
+		healthz.NamedCheck("ping", func() error { return nil }),
+		healthz.NamedCheck("leader", func() error {
+			if !isLeader.Load() {
+				return fmt.Errorf("not leader")
+			}
+			return nil
+		}),
+		healthz.NamedCheck("apiserver-reachable", func() error {
+			discoveryClient, err := discovery.NewDiscoveryClientForConfig(mgr.GetConfig())
+			if err != nil {
+				return fmt.Errorf("unable to build discovery client: %v", err)
+			}
+			_, err = discoveryClient.ServerVersion()
+			return err
+		}),
+		healthz.NamedCheck("informer-synced", func() error {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if !mgr.GetCache().WaitForCacheSync(ctx) {
+				return fmt.Errorf("controller caches not yet synced")
+			}
+			return nil
+		}),
+		// aenv-controller is the aenvhub server, not a client of one, so it
+		// has no outbound schedule-client dependency to probe today; kept as
+		// a named no-op so the check inventory matches operators' expectations.
+		healthz.NamedCheck("schedule-client", func() error { return nil }),
+		healthz.NamedCheck("storage", func() error {
+			store := storage.NewCRDEnvStorage(mgr.GetClient(), defaultNamespace)
+			_, err := store.List(context.Background(), nil)
+			return err
+		}),
+	}
+	handler := healthz.NewHandler(checks...)
+
 	// Listen to mgr.Elected(), set flag to true when becoming leader
-	go func() {
+	safeGo("leader-elected-http-server", func() {
 		<-mgr.Elected() // When closed, it means leader has been acquired
 		isLeader.Store(true)
 
 		klog.Infof("This controller is now the leader")
 
-		StartHttpServer()
-	}()
+		StartHttpServer(mgr.GetClient())
+	})
 
-	// readiness API
-	readyzHandler := func(w http.ResponseWriter, r *http.Request) {
-		if isLeader.Load() {
-			w.WriteHeader(http.StatusOK)
-			_, _ = w.Write([]byte("ok"))
-		} else {
-			w.WriteHeader(http.StatusBadRequest)
-			_, _ = w.Write([]byte("not leader"))
-		}
-	}
-	// starts readiness server
-	go func() {
+	// starts health/config server
+	safeGo("health-server", func() {
 		mux := http.NewServeMux()
-		mux.HandleFunc("/readyz", readyzHandler)
+		mux.HandleFunc("/livez", handler.Livez())
+		mux.HandleFunc("/livez/", handler.Livez())
+		mux.HandleFunc("/readyz", handler.Readyz())
+		mux.HandleFunc("/readyz/", handler.Readyz())
+		mux.HandleFunc("/healthz", handler.Healthz())
+		mux.HandleFunc("/healthz/", handler.Healthz())
+		mux.HandleFunc("/configz", configzHandler)
 		srv := &http.Server{
 			Addr:    ":8081",
 			Handler: mux,
@@ -225,11 +388,52 @@ func AddReadiness(mgr manager.Manager) {
 		if err := srv.ListenAndServe(); err != nil {
 			klog.Errorf("server error: %v\n", err)
 		}
-	}()
+	})
+}
+
+// configz is the effective configuration reported at /configz, so operators
+// can verify a running instance without shelling into the container.
+type configz struct {
+	Namespace                string `json:"namespace"`
+	ServerPort               int    `json:"serverPort"`
+	QPS                      int    `json:"qps"`
+	Burst                    int    `json:"burst"`
+	LeaderElect              bool   `json:"leaderElect"`
+	LeaderElectLeaseDuration string `json:"leaderElectLeaseDuration"`
+	LeaderElectRenewDeadline string `json:"leaderElectRenewDeadline"`
+	LeaderElectRetryPeriod   string `json:"leaderElectRetryPeriod"`
+	ClustersConfig           string `json:"clustersConfig,omitempty"`
+	ReapInterval             string `json:"reapInterval"`
+	ReapGracePeriodSeconds   int64  `json:"reapGracePeriodSeconds"`
+	MaxParallelOps           int    `json:"maxParallelOps"`
+	CacheResyncPeriod        string `json:"cacheResyncPeriod"`
+}
+
+func configzHandler(w http.ResponseWriter, r *http.Request) {
+	cfg := configz{
+		Namespace:                defaultNamespace,
+		ServerPort:               serverPort,
+		QPS:                      qps,
+		Burst:                    burst,
+		LeaderElect:              enableLeaderElection,
+		LeaderElectLeaseDuration: leaderDuration,
+		LeaderElectRenewDeadline: leaderRenewDuration,
+		LeaderElectRetryPeriod:   leaderRetryPeriodDuation,
+		ClustersConfig:           clustersConfig,
+		ReapInterval:             reapInterval.String(),
+		ReapGracePeriodSeconds:   reapGracePeriod,
+		MaxParallelOps:           maxParallelOps,
+		CacheResyncPeriod:        cacheResync.String(),
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(cfg)
 }
 
 // AddToManagerFuncs is a list of functions to add all Controllers to the Manager
-var AddToManagerFuncs = map[string]func(manager.Manager) error{}
+var AddToManagerFuncs = map[string]func(manager.Manager) error{
+	"env-controller":          aenvcontroller.Add,
+	"aenvinstance-controller": aenvcontroller.AddInstanceController,
+}
 
 // AddToManager adds all Controllers to the Manager
 // Automatically generate RBAC rules to allow the Controller to leader election
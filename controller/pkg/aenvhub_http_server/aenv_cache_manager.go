@@ -0,0 +1,413 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aenvhub_http_server
+
+import (
+	"context"
+	"controller/pkg/constants"
+	"controller/pkg/storage"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+)
+
+// envIndexName indexes cached Pods by their envInstanceName label, so
+// ListPodsByEnv doesn't need to walk every pod in every scope.
+const envIndexName = "byEnvInstanceName"
+
+// ttlIndexName indexes cached Pods by their constants.AENV_TTL label, so
+// callers can find every TTL-bearing pod without walking every pod in every
+// scope (e.g. a future reaper that wants to re-check only labeled pods).
+const ttlIndexName = "byTTLLabel"
+
+func envIndexFunc(obj interface{}) ([]string, error) {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return nil, nil
+	}
+	v, ok := pod.Labels[envInstanceName]
+	if !ok || v == "" {
+		return nil, nil
+	}
+	return []string{v}, nil
+}
+
+func ttlIndexFunc(obj interface{}) ([]string, error) {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return nil, nil
+	}
+	v, ok := pod.Labels[constants.AENV_TTL]
+	if !ok || v == "" {
+		return nil, nil
+	}
+	return []string{v}, nil
+}
+
+// CacheScope restricts one informer to a namespace (or "" for all
+// namespaces) and a label/field selector, mirroring controller-runtime's
+// cache.Options{SelectorsByObject: ...} pattern.
+type CacheScope struct {
+	Namespace     string
+	LabelSelector string
+	FieldSelector string
+}
+
+func (s CacheScope) tweakListOptions(options *metav1.ListOptions) {
+	options.LabelSelector = s.LabelSelector
+	options.FieldSelector = s.FieldSelector
+}
+
+func newScopedFactory(clientset kubernetes.Interface, scope CacheScope, resync time.Duration) informers.SharedInformerFactory {
+	return informers.NewFilteredSharedInformerFactory(clientset, resync, scope.Namespace, scope.tweakListOptions)
+}
+
+// AEnvCacheManager caches Pods, Nodes and ConfigMaps across an arbitrary
+// number of (namespace, labelSelector, fieldSelector) scopes instead of one
+// cache per watched namespace. Each scope gets its own filtered informer
+// factory, so a cluster with many namespaces no longer needs one full
+// namespace-wide cache per namespace just to find AEnv-owned pods.
+type AEnvCacheManager struct {
+	podIndexers  []cache.Indexer
+	podInformers []cache.Controller
+
+	nodeIndexers  []cache.Indexer
+	nodeInformers []cache.Controller
+
+	configMapIndexers  []cache.Indexer
+	configMapInformers []cache.Controller
+
+	// events is where pod state transitions (Pending->Running, Running->Failed,
+	// TTL-expired) are published; see watchPod for the consuming SSE endpoint.
+	events *PodEventBus
+
+	// envStore, when set via SetEnvStore, lets ListExpiredPods fall back to
+	// the owning Env's ExpiredTime for pods that have no AENV_TTL label of
+	// their own. Nil means only the per-pod label is honored, as before.
+	envStore storage.EnvStorage
+
+	stopCh chan struct{}
+}
+
+// SetEnvStore wires envStore into the cache manager so ListExpiredPods can
+// enforce Env.ExpiredTime for pods that don't carry their own AENV_TTL label.
+func (m *AEnvCacheManager) SetEnvStore(envStore storage.EnvStorage) {
+	m.envStore = envStore
+}
+
+// defaultCacheResyncPeriod is used when NewAEnvCacheManager is called with
+// resync <= 0, preserving the period every scope's informer used before this
+// became configurable.
+const defaultCacheResyncPeriod = 5 * time.Minute
+
+// NewAEnvCacheManager builds and starts informers for podScopes, nodeScopes
+// and configMapScopes, blocking until every one of them has synced. resync is
+// the period each informer's reflector does a full relist; <= 0 falls back
+// to defaultCacheResyncPeriod.
+func NewAEnvCacheManager(clientset kubernetes.Interface, podScopes, nodeScopes, configMapScopes []CacheScope, resync time.Duration) *AEnvCacheManager {
+	if resync <= 0 {
+		resync = defaultCacheResyncPeriod
+	}
+	klog.Infof("AEnv cache manager initialization starts (pod scopes: %d, node scopes: %d, configmap scopes: %d, resync: %v)", len(podScopes), len(nodeScopes), len(configMapScopes), resync)
+
+	m := &AEnvCacheManager{stopCh: make(chan struct{}), events: NewPodEventBus()}
+
+	for _, scope := range podScopes {
+		factory := newScopedFactory(clientset, scope, resync)
+		podInformer := factory.Core().V1().Pods().Informer()
+		if err := podInformer.AddIndexers(cache.Indexers{envIndexName: envIndexFunc, ttlIndexName: ttlIndexFunc}); err != nil {
+			klog.Errorf("failed to add indexers for scope %+v: %v", scope, err)
+		}
+		podInformer.AddEventHandler(m.podEventHandlers())
+		m.podIndexers = append(m.podIndexers, podInformer.GetIndexer())
+		m.podInformers = append(m.podInformers, podInformer)
+		runInformer(podInformer, m.stopCh)
+	}
+
+	for _, scope := range nodeScopes {
+		factory := newScopedFactory(clientset, scope, resync)
+		nodeInformer := factory.Core().V1().Nodes().Informer()
+		m.nodeIndexers = append(m.nodeIndexers, nodeInformer.GetIndexer())
+		m.nodeInformers = append(m.nodeInformers, nodeInformer)
+		runInformer(nodeInformer, m.stopCh)
+	}
+
+	for _, scope := range configMapScopes {
+		factory := newScopedFactory(clientset, scope, resync)
+		cmInformer := factory.Core().V1().ConfigMaps().Informer()
+		m.configMapIndexers = append(m.configMapIndexers, cmInformer.GetIndexer())
+		m.configMapInformers = append(m.configMapInformers, cmInformer)
+		runInformer(cmInformer, m.stopCh)
+	}
+
+	if !m.WaitForCacheSync(m.stopCh) {
+		klog.Fatalf("failed to wait for cache sync!")
+	}
+
+	klog.Infof("AEnv cache manager initialization finished, %d pods cached", len(m.listAllPods()))
+	return m
+}
+
+// runInformer starts informer in its own goroutine, recovering (and logging,
+// via klog through the default crash handler) any panic so one misbehaving
+// informer can't take the whole process down.
+func runInformer(informer cache.Controller, stopCh <-chan struct{}) {
+	go func() {
+		defer utilruntime.HandleCrash()
+		informer.Run(stopCh)
+	}()
+}
+
+// podEventHandlers builds the AddFunc/UpdateFunc/DeleteFunc trio that
+// classifies pod state transitions and publishes them on m.events.
+func (m *AEnvCacheManager) podEventHandlers() cache.ResourceEventHandlerFuncs {
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			pod, ok := obj.(*corev1.Pod)
+			if !ok {
+				return
+			}
+			m.events.publish(PodEvent{Type: PodEventAdded, Namespace: pod.Namespace, PodName: pod.Name, Phase: pod.Status.Phase, Timestamp: time.Now()})
+			if pod.Status.Phase == corev1.PodRunning {
+				m.events.publish(PodEvent{Type: PodEventRunning, Namespace: pod.Namespace, PodName: pod.Name, Phase: pod.Status.Phase, Timestamp: time.Now()})
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldPod, ok := oldObj.(*corev1.Pod)
+			if !ok {
+				return
+			}
+			newPod, ok := newObj.(*corev1.Pod)
+			if !ok || oldPod.Status.Phase == newPod.Status.Phase {
+				return
+			}
+			switch {
+			case oldPod.Status.Phase == corev1.PodPending && newPod.Status.Phase == corev1.PodRunning:
+				m.events.publish(PodEvent{Type: PodEventRunning, Namespace: newPod.Namespace, PodName: newPod.Name, Phase: newPod.Status.Phase, Timestamp: time.Now()})
+			case newPod.Status.Phase == corev1.PodFailed:
+				m.events.publish(PodEvent{Type: PodEventFailed, Namespace: newPod.Namespace, PodName: newPod.Name, Phase: newPod.Status.Phase, Timestamp: time.Now()})
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			pod, ok := obj.(*corev1.Pod)
+			if !ok {
+				if tombstone, isTombstone := obj.(cache.DeletedFinalStateUnknown); isTombstone {
+					pod, ok = tombstone.Obj.(*corev1.Pod)
+				}
+				if !ok {
+					return
+				}
+			}
+			eventType := PodEventDeleted
+			if pod.Labels[constants.AENV_TTL] != "" {
+				eventType = PodEventTTLExpired
+			}
+			m.events.publish(PodEvent{Type: eventType, Namespace: pod.Namespace, PodName: pod.Name, Phase: pod.Status.Phase, Timestamp: time.Now()})
+		},
+	}
+}
+
+// Events returns the bus pod state transitions are published on, so
+// AEnvPodHandler can expose GET /pods/{name}/watch (SSE) and other
+// subscribers (e.g. the api-service MCP gateway) can consume it over HTTP.
+func (m *AEnvCacheManager) Events() *PodEventBus {
+	return m.events
+}
+
+// WaitForCacheSync waits for every informer owned by m to finish its initial sync.
+func (m *AEnvCacheManager) WaitForCacheSync(stopCh <-chan struct{}) bool {
+	synced := true
+	for _, group := range [][]cache.Controller{m.podInformers, m.nodeInformers, m.configMapInformers} {
+		for _, inf := range group {
+			if !cache.WaitForCacheSync(stopCh, inf.HasSynced) {
+				synced = false
+			}
+		}
+	}
+	return synced
+}
+
+// Stop tears down every informer owned by m.
+func (m *AEnvCacheManager) Stop() {
+	close(m.stopCh)
+}
+
+func (m *AEnvCacheManager) listAllPods() []*corev1.Pod {
+	var pods []*corev1.Pod
+	for _, idx := range m.podIndexers {
+		for _, item := range idx.List() {
+			pods = append(pods, item.(*corev1.Pod))
+		}
+	}
+	return pods
+}
+
+// GetPod looks up a single pod by namespace/name across every pod scope.
+func (m *AEnvCacheManager) GetPod(namespace, name string) (*corev1.Pod, error) {
+	key := fmt.Sprintf("%s/%s", namespace, name)
+	for _, idx := range m.podIndexers {
+		item, exists, err := idx.GetByKey(key)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return item.(*corev1.Pod), nil
+		}
+	}
+	return nil, fmt.Errorf("pod %s not found in cache", key)
+}
+
+// ListPodsByNamespace lists cached pods in namespace across every pod scope.
+func (m *AEnvCacheManager) ListPodsByNamespace(namespace string) ([]*corev1.Pod, error) {
+	var pods []*corev1.Pod
+	for _, idx := range m.podIndexers {
+		items, err := idx.ByIndex(cache.NamespaceIndex, namespace)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			pods = append(pods, item.(*corev1.Pod))
+		}
+	}
+	return pods, nil
+}
+
+// ListPodsBySelector lists every cached pod, across every scope, matching selector.
+func (m *AEnvCacheManager) ListPodsBySelector(selector labels.Selector) ([]*corev1.Pod, error) {
+	var pods []*corev1.Pod
+	for _, pod := range m.listAllPods() {
+		if selector.Matches(labels.Set(pod.Labels)) {
+			pods = append(pods, pod)
+		}
+	}
+	return pods, nil
+}
+
+// ListPodsByEnv lists cached pods carrying envInstanceName=envID, using the
+// envIndexName indexer rather than a full scan.
+func (m *AEnvCacheManager) ListPodsByEnv(envID string) ([]*corev1.Pod, error) {
+	var pods []*corev1.Pod
+	for _, idx := range m.podIndexers {
+		items, err := idx.ByIndex(envIndexName, envID)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			pods = append(pods, item.(*corev1.Pod))
+		}
+	}
+	return pods, nil
+}
+
+// ListExpiredPods lists pods in namespace whose AENV_TTL label shows they
+// have outlived their TTL, falling back to the owning Env's ExpiredTime
+// (via envStore, when set) for pods with no AENV_TTL label of their own.
+// Pods labeled prunePolicyLabel=false are skipped regardless of age, the way
+// `kubectl apply --prune` honors an exclusion label. ttlOverride, when
+// non-zero, replaces every pod's own TTL for this call instead of the value
+// parsed from its label or owning Env.
+func (m *AEnvCacheManager) ListExpiredPods(ctx context.Context, namespace string, ttlOverride time.Duration) ([]*corev1.Pod, error) {
+	pods, err := m.ListPodsByNamespace(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	expired := make([]*corev1.Pod, 0)
+	for _, pod := range pods {
+		if pod.Labels[prunePolicyLabel] == "false" {
+			continue
+		}
+
+		limited, ok := m.ttlForPod(ctx, pod)
+		if !ok {
+			continue
+		}
+		if ttlOverride > 0 {
+			limited = ttlOverride
+		}
+
+		createdAt := pod.CreationTimestamp
+		if time.Since(createdAt.Time) <= limited {
+			continue
+		}
+		klog.Infof("pod %s has expired (created: %s, ttl: %v)", pod.Name, createdAt, limited)
+		expired = append(expired, pod)
+	}
+	return expired, nil
+}
+
+// ttlForPod resolves the TTL to enforce for pod: its own AENV_TTL label
+// first, falling back to the owning Env's ExpiredTime (looked up by the
+// envInstanceName label through envStore) when the pod carries no label of
+// its own. ok is false when neither source yields a usable TTL.
+func (m *AEnvCacheManager) ttlForPod(ctx context.Context, pod *corev1.Pod) (time.Duration, bool) {
+	if ttlValue := pod.Labels[constants.AENV_TTL]; ttlValue != "" {
+		limited, err := time.ParseDuration(ttlValue)
+		if err != nil {
+			klog.Warningf("Failed to parse ttl value %s for pod %s will not auto clean", ttlValue, pod.Name)
+			return 0, false
+		}
+		return limited, true
+	}
+
+	if m.envStore == nil {
+		return 0, false
+	}
+	envName := pod.Labels[envInstanceName]
+	if envName == "" {
+		return 0, false
+	}
+	env, err := m.envStore.Get(ctx, envName)
+	if err != nil || env == nil || env.ExpiredTime <= 0 {
+		return 0, false
+	}
+	return time.Duration(env.ExpiredTime) * time.Second, true
+}
+
+// GetConfigMap looks up a single ConfigMap by namespace/name across every configmap scope.
+func (m *AEnvCacheManager) GetConfigMap(namespace, name string) (*corev1.ConfigMap, error) {
+	key := fmt.Sprintf("%s/%s", namespace, name)
+	for _, idx := range m.configMapIndexers {
+		item, exists, err := idx.GetByKey(key)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return item.(*corev1.ConfigMap), nil
+		}
+	}
+	return nil, fmt.Errorf("configmap %s not found in cache", key)
+}
+
+// ListNodes lists every cached node across every node scope.
+func (m *AEnvCacheManager) ListNodes() ([]*corev1.Node, error) {
+	var nodes []*corev1.Node
+	for _, idx := range m.nodeIndexers {
+		for _, item := range idx.List() {
+			nodes = append(nodes, item.(*corev1.Node))
+		}
+	}
+	return nodes, nil
+}
@@ -19,16 +19,22 @@ package aenvhub_http_server
 import (
 	"encoding/json"
 	"fmt"
+	"math/rand"
 	"net/http"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"controller/pkg/constants"
 
 	"k8s.io/apimachinery/pkg/api/resource"
 
+	aenvv1alpha1 "controller/pkg/apis/aenv/v1alpha1"
+	"controller/pkg/cluster"
 	"controller/pkg/model"
+	"controller/pkg/parallel"
+	"controller/pkg/storage"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -37,18 +43,63 @@ import (
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // AEnvPodHandler handles Kubernetes Pod CRUD operations
 // Note: AEnvPodHandler only handles one namespace, which is read from pod template.
 type AEnvPodHandler struct {
-	clientset kubernetes.Interface
-	podCache  *AEnvPodCache
-	namespace string
+	clientset    kubernetes.Interface
+	config       *rest.Config
+	cacheManager *AEnvCacheManager
+	namespace    string
+
+	// clusters, when non-empty, lets createPod spread AEnvHubEnvs across
+	// member clusters instead of always using the local clientset above.
+	clusters *cluster.ClusterStore
+
+	// reaper sweeps namespace for TTL-expired pods, both on its own
+	// background interval and on demand via POST /pods/prune.
+	reaper *PodReaper
+
+	// pool, when set via SetPrewarmPool, bounds how many pod create/delete
+	// calls run concurrently for prewarm pool fills; nil means callers fall
+	// back to creating pods sequentially.
+	pool *parallel.Pool
+	// prewarmReconciler keeps every registered env's pool at its desired
+	// size once StartPrewarmReconciler is called.
+	prewarmReconciler *PrewarmReconciler
+
+	// instanceClient, when set via SetInstanceClient, makes createPod create
+	// an AEnvInstance CR instead of a Pod directly; controller.InstanceReconciler
+	// then owns rendering and TTL-expiring the backing Pod. Nil means
+	// createPod falls back to the legacy imperative Pod-create path.
+	instanceClient client.Client
+
+	// retry queues legacy-path pod create/delete calls behind a rate limiter
+	// so a burst of requests degrades into backed-off apiserver traffic
+	// instead of every caller hitting the apiserver at once; see
+	// createPod/deletePod's sync=true escape hatch for the prior behavior.
+	retry *RetryController
+
+	// ttlGC schedules punctual, finalizer-guaranteed cleanup for TTL-labeled
+	// pods, alongside the reaper's periodic sweep.
+	ttlGC *TTLGCController
+
+	// resourcePolicy validates an AEnvHubEnv's deploy config before createPod
+	// sends its pod to Pods().Create; see SetResourcePolicy.
+	resourcePolicy ResourcePolicy
+
+	// spreadMu guards spreadCounts, the per-env/dimension/value placement
+	// tallies selectCluster uses to honor Scheduling.SpreadConstraints.
+	spreadMu     sync.Mutex
+	spreadCounts map[string]int
 }
 
-// NewAEnvPodHandler creates new PodHandler
-func NewAEnvPodHandler() (*AEnvPodHandler, error) {
+// NewAEnvPodHandler creates new PodHandler. cacheResync is the informer
+// resync period passed through to NewAEnvCacheManager; <= 0 falls back to
+// defaultCacheResyncPeriod.
+func NewAEnvPodHandler(cacheResync time.Duration) (*AEnvPodHandler, error) {
 	config, err := rest.InClusterConfig()
 	if err != nil {
 		kubeconfig := os.Getenv("KUBECONFIG")
@@ -74,26 +125,252 @@ func NewAEnvPodHandler() (*AEnvPodHandler, error) {
 
 	podHandler := &AEnvPodHandler{
 		clientset: clientset,
+		config:    config,
 	}
 
 	// Get namespace
 	namespace := LoadNsFromPodTemplate(SingleContainerTemplate)
 	podHandler.namespace = namespace
 
-	// Initialize Pod cache for namespace
-	podCache := NewAEnvPodCache(clientset, namespace)
-	podHandler.podCache = podCache
+	// Initialize multi-scope cache manager for namespace. A single scope
+	// covering the whole namespace preserves prior behavior; callers that
+	// want finer-grained, label-scoped caching can build AEnvCacheManager
+	// directly with more CacheScopes.
+	cacheManager := NewAEnvCacheManager(clientset,
+		[]CacheScope{{Namespace: namespace}},
+		[]CacheScope{{}},
+		[]CacheScope{{Namespace: namespace}},
+		cacheResync,
+	)
+	podHandler.cacheManager = cacheManager
+
+	podHandler.reaper = NewPodReaper(clientset, cacheManager, namespace, defaultReapInterval, defaultReapGracePeriodSeconds)
+	podHandler.retry = NewRetryController(clientset, namespace, parallel.NewTokenBucketRateLimiter(1, 10))
+	podHandler.ttlGC = NewTTLGCController(clientset, cacheManager, namespace, nil)
+	podHandler.resourcePolicy = NewDefaultResourcePolicy()
 
 	klog.Infof("AEnv pod handler is created, namespace is %s", podHandler.namespace)
 
 	return podHandler, nil
 }
 
+// SetReapConfig overrides the reaper's sweep interval and delete grace
+// period. Call before StartReaper; matches the optional SetClusterStore
+// configuration pattern.
+func (h *AEnvPodHandler) SetReapConfig(interval time.Duration, gracePeriodSeconds int64) {
+	h.reaper.interval = interval
+	h.reaper.gracePeriodSeconds = gracePeriodSeconds
+}
+
+// SetResourcePolicy overrides the default resource/TTL/env-var validation
+// createPod runs before sending a pod to Pods().Create. Passing nil disables
+// validation entirely.
+func (h *AEnvPodHandler) SetResourcePolicy(policy ResourcePolicy) {
+	h.resourcePolicy = policy
+}
+
+// StartReaper launches the background TTL sweep; closing stopCh stops it.
+func (h *AEnvPodHandler) StartReaper(stopCh <-chan struct{}) {
+	go h.reaper.Run(stopCh)
+}
+
+// defaultRetryWorkers bounds how many queued create/delete calls run
+// concurrently against the apiserver.
+const defaultRetryWorkers = 4
+
+// StartRetryController launches the background workers that drain queued
+// create/delete calls; closing stopCh stops it.
+func (h *AEnvPodHandler) StartRetryController(stopCh <-chan struct{}) {
+	go h.retry.Run(defaultRetryWorkers, stopCh)
+}
+
+// SetTTLCleanupHook wires a downstream cleanup hook (e.g. a Redis instance
+// counter decrement, a backend notification) into the TTL GC controller, run
+// before a TTL-expired pod's finalizer is cleared. Call before StartTTLGC.
+// Unset (the default), the TTL GC controller runs noopTTLCleanupHook
+// instead, which logs a warning per expiry rather than cleaning anything up.
+func (h *AEnvPodHandler) SetTTLCleanupHook(hook TTLCleanupHook) {
+	h.ttlGC.hook = hook
+}
+
+// StartTTLGC launches the background TTL garbage-collection controller;
+// closing stopCh stops it.
+func (h *AEnvPodHandler) StartTTLGC(stopCh <-chan struct{}) {
+	go h.ttlGC.Run(stopCh)
+}
+
+// SetPrewarmPool wires a bounded parallel.Pool (sized by e.g.
+// --max-parallel-ops) into this handler and builds a PrewarmReconciler on
+// top of it, so registered envs' pools get filled/drained concurrently
+// instead of one pod at a time. Call before StartPrewarmReconciler.
+func (h *AEnvPodHandler) SetPrewarmPool(pool *parallel.Pool) {
+	h.pool = pool
+	h.prewarmReconciler = NewPrewarmReconciler(h, pool)
+}
+
+// StartPrewarmReconciler launches the background prewarm reconcile loop;
+// closing stopCh stops it. No-op if SetPrewarmPool was never called.
+func (h *AEnvPodHandler) StartPrewarmReconciler(stopCh <-chan struct{}) {
+	if h.prewarmReconciler == nil {
+		return
+	}
+	go h.prewarmReconciler.Run(stopCh)
+}
+
+// SetClusterStore registers the federation-style cluster registry this
+// handler should place pods onto. When unset (the default), every pod is
+// created against the local clientset as before.
+func (h *AEnvPodHandler) SetClusterStore(store *cluster.ClusterStore) {
+	h.clusters = store
+}
+
+// SetInstanceClient wires a controller-runtime client into this handler so
+// createPod creates/updates an AEnvInstance CR, handing pod lifecycle off to
+// controller.InstanceReconciler, instead of creating a Pod directly. When
+// unset (the default), createPod keeps its legacy imperative behavior.
+func (h *AEnvPodHandler) SetInstanceClient(c client.Client) {
+	h.instanceClient = c
+}
+
+// SetEnvStore wires envStore into this handler's cache manager so the
+// reaper/prune endpoints can fall back to a pod's owning Env.ExpiredTime
+// when the pod carries no AENV_TTL label of its own. When unset (the
+// default), only the per-pod label is honored.
+func (h *AEnvPodHandler) SetEnvStore(envStore storage.EnvStorage) {
+	h.cacheManager.SetEnvStore(envStore)
+}
+
+// selectCluster picks the member cluster to place aenvHubEnv on, matching
+// Scheduling.ClusterSelector, narrowing to the least-placed value of
+// Scheduling.SpreadConstraints' first dimension (if set), and breaking ties
+// by Scheduling.ClusterWeights (falling back to the cluster's own Weight).
+// Returns "" when no cluster registry is configured, meaning the caller
+// should use the local clientset.
+func (h *AEnvPodHandler) selectCluster(aenv *model.AEnvHubEnv) (string, error) {
+	if h.clusters == nil || h.clusters.Len() == 0 {
+		return "", nil
+	}
+
+	var candidates []*cluster.Cluster
+	for _, c := range h.clusters.List() {
+		if c.Matches(aenv.Scheduling.ClusterSelector) {
+			candidates = append(candidates, c)
+		}
+	}
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("no member cluster matches selector %v", aenv.Scheduling.ClusterSelector)
+	}
+
+	candidates = h.applySpreadConstraints(aenv, candidates)
+
+	var totalWeight int32
+	weights := make([]int32, len(candidates))
+	for i, c := range candidates {
+		w := c.Weight
+		if override, ok := aenv.Scheduling.ClusterWeights[c.Name]; ok {
+			w = override
+		}
+		if w <= 0 {
+			w = 1
+		}
+		weights[i] = w
+		totalWeight += w
+	}
+
+	pick := rand.Int31n(totalWeight)
+	chosen := candidates[len(candidates)-1]
+	for i, c := range candidates {
+		if pick < weights[i] {
+			chosen = c
+			break
+		}
+		pick -= weights[i]
+	}
+	h.recordPlacement(aenv, chosen)
+	return chosen.Name, nil
+}
+
+// applySpreadConstraints narrows candidates down to whichever value of
+// Scheduling.SpreadConstraints' first dimension (e.g. "zone") has the fewest
+// placements recorded for aenv so far, so repeated creates for the same env
+// fan out across that dimension instead of weighted-random favoring whatever
+// value happens to have more/heavier-weighted clusters. Candidates without
+// the dimension labeled fall into the "" value group like any other value.
+// No-op when SpreadConstraints is empty.
+func (h *AEnvPodHandler) applySpreadConstraints(aenv *model.AEnvHubEnv, candidates []*cluster.Cluster) []*cluster.Cluster {
+	if len(aenv.Scheduling.SpreadConstraints) == 0 {
+		return candidates
+	}
+	dimension := aenv.Scheduling.SpreadConstraints[0]
+
+	byValue := make(map[string][]*cluster.Cluster)
+	var order []string
+	for _, c := range candidates {
+		value := c.Labels[dimension]
+		if _, seen := byValue[value]; !seen {
+			order = append(order, value)
+		}
+		byValue[value] = append(byValue[value], c)
+	}
+
+	h.spreadMu.Lock()
+	defer h.spreadMu.Unlock()
+
+	var best []*cluster.Cluster
+	bestCount := -1
+	for _, value := range order {
+		count := h.spreadCounts[h.spreadKey(aenv.Name, dimension, value)]
+		if bestCount == -1 || count < bestCount {
+			bestCount = count
+			best = byValue[value]
+		}
+	}
+	return best
+}
+
+// recordPlacement tallies chosen against Scheduling.SpreadConstraints' first
+// dimension so the next applySpreadConstraints call for the same env favors
+// a different value. No-op when SpreadConstraints is empty.
+func (h *AEnvPodHandler) recordPlacement(aenv *model.AEnvHubEnv, chosen *cluster.Cluster) {
+	if len(aenv.Scheduling.SpreadConstraints) == 0 {
+		return
+	}
+	dimension := aenv.Scheduling.SpreadConstraints[0]
+	value := chosen.Labels[dimension]
+
+	h.spreadMu.Lock()
+	defer h.spreadMu.Unlock()
+	if h.spreadCounts == nil {
+		h.spreadCounts = make(map[string]int)
+	}
+	h.spreadCounts[h.spreadKey(aenv.Name, dimension, value)]++
+}
+
+// spreadKey namespaces a spreadCounts entry by env name, spread dimension
+// and the dimension's value so different envs/dimensions don't share tallies.
+func (h *AEnvPodHandler) spreadKey(envName, dimension, value string) string {
+	return envName + "|" + dimension + "|" + value
+}
+
+// clientsetForCluster resolves the clientset a pod operation should use: the
+// named member cluster's if clusterName is non-empty and registered,
+// otherwise the handler's local clientset.
+func (h *AEnvPodHandler) clientsetForCluster(clusterName string) (kubernetes.Interface, error) {
+	if clusterName == "" || h.clusters == nil {
+		return h.clientset, nil
+	}
+	c, ok := h.clusters.Get(clusterName)
+	if !ok {
+		return nil, fmt.Errorf("unknown cluster %q", clusterName)
+	}
+	return c.Clientset()
+}
+
 // ServeHTTP main routing method
 func (h *AEnvPodHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	parts := strings.Split(r.URL.Path, "/")
-	if len(parts) < 2 || parts[1] != "pods" {
+	if len(parts) < 2 || (parts[1] != "pods" && parts[1] != "tasks" && parts[1] != "env-instance") {
 		http.Error(w, "Invalid URL path", http.StatusBadRequest)
 		return
 	}
@@ -101,10 +378,19 @@ func (h *AEnvPodHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	// Route handling
 	switch {
+	case r.Method == http.MethodPost && parts[1] == "env-instance" && len(parts) == 3 && parts[2] == "manifest": // /env-instance/manifest
+		h.applyManifest(w, r)
+	case r.Method == http.MethodGet && parts[1] == "tasks" && len(parts) == 3: // /tasks/{taskID}
+		h.getTask(parts[2], w, r)
 	case r.Method == http.MethodPost && len(parts) == 2: // /pods
 		h.createPod(w, r)
+	case r.Method == http.MethodPost && len(parts) == 3 && parts[2] == "prune": // /pods/prune
+		h.prunePods(w, r)
 	case r.Method == http.MethodGet && len(parts) == 2: // /pods/
 		h.listPod(w, r)
+	case r.Method == http.MethodGet && len(parts) == 4 && parts[3] == "watch": // /pods/{podName}/watch
+		podName := parts[2]
+		h.watchPod(podName, w, r)
 	case r.Method == http.MethodGet && len(parts) == 3: // /pods/{podName}
 		podName := parts[2]
 		h.getPod(podName, w, r)
@@ -165,10 +451,11 @@ response is:
 */
 
 type HttpResponseData struct {
-	ID     string `json:"id"`
-	Status string `json:"status"`
-	IP     string `json:"ip"`
-	TTL    string `json:"ttl"`
+	ID      string `json:"id"`
+	Status  string `json:"status"`
+	IP      string `json:"ip"`
+	TTL     string `json:"ttl"`
+	Cluster string `json:"cluster,omitempty"`
 }
 type HttpResponse struct {
 	Success      bool             `json:"success"`
@@ -181,6 +468,20 @@ type HttpDeleteResponse struct {
 	ResponseData bool `json:"data"`
 }
 
+// HttpTaskResponseData reports a queued create/delete call's initial state,
+// for callers to poll at GET /tasks/{id}.
+type HttpTaskResponseData struct {
+	ID      string      `json:"id"`
+	Op      PodOpType   `json:"op"`
+	PodName string      `json:"podName"`
+	Status  PodOpStatus `json:"status"`
+}
+type HttpTaskResponse struct {
+	Success      bool                 `json:"success"`
+	Code         int                  `json:"code"`
+	ResponseData HttpTaskResponseData `json:"data"`
+}
+
 /*
 *
 
@@ -194,6 +495,7 @@ type HttpListResponseData struct {
 	ID        string    `json:"id"`
 	Status    string    `json:"status"`
 	TTL       string    `json:"ttl"`
+	Cluster   string    `json:"cluster,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
 }
 type HttpListResponse struct {
@@ -210,6 +512,25 @@ func (h *AEnvPodHandler) createPod(w http.ResponseWriter, r *http.Request) {
 	}
 	defer r.Body.Close()
 
+	if h.resourcePolicy != nil {
+		if err := h.resourcePolicy.Validate(h.namespace, &aenvHubEnv); err != nil {
+			writeResourcePolicyError(w, err)
+			return
+		}
+	}
+
+	// Pick the member cluster this instance should be created on, if any are registered.
+	chosenCluster, err := h.selectCluster(&aenvHubEnv)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to place pod: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if h.instanceClient != nil {
+		h.createInstance(w, r, &aenvHubEnv, chosenCluster)
+		return
+	}
+
 	// Get podTemplate type, default to "singleContainer"
 	templateType := SingleContainerTemplate
 	if podTemplateValue, ok := aenvHubEnv.DeployConfig["podTemplate"]; ok {
@@ -226,6 +547,20 @@ func (h *AEnvPodHandler) createPod(w http.ResponseWriter, r *http.Request) {
 
 	klog.Infof("rendered pod template: %v", pod.Spec.Containers[0].Env)
 
+	overlay, err := podOverlayFromDeployConfig(aenvHubEnv.DeployConfig)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid podOverlay: %v", err), http.StatusBadRequest)
+		return
+	}
+	if !overlay.IsEmpty() {
+		overlaid, err := ApplyOverlay(pod, overlay)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to apply pod overlay: %v", err), http.StatusBadRequest)
+			return
+		}
+		pod = overlaid
+	}
+
 	// Generate name
 	pod.Name = fmt.Sprintf("%s-%s", aenvHubEnv.Name, RandString(6))
 	// Set pods TTL by label
@@ -237,10 +572,33 @@ func (h *AEnvPodHandler) createPod(w http.ResponseWriter, r *http.Request) {
 		}
 		ttlValue := aenvHubEnv.DeployConfig["ttl"].(string)
 		labels[constants.AENV_TTL] = ttlValue
+		pod.Finalizers = append(pod.Finalizers, TTLFinalizer)
 		klog.Infof("add aenv-ttl label with value:%v for pod:%s", ttlValue, pod.Name)
 	}
 
-	createdPod, err := h.clientset.CoreV1().Pods(h.namespace).Create(r.Context(), pod, metav1.CreateOptions{})
+	clientset, err := h.clientsetForCluster(chosenCluster)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to resolve cluster: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if chosenCluster != "" {
+		if pod.Labels == nil {
+			pod.Labels = make(map[string]string)
+		}
+		pod.Labels[clusterLabel] = chosenCluster
+		klog.Infof("placed pod %s on member cluster %s", pod.Name, chosenCluster)
+	}
+
+	// The retry queue only ever talks to h.clientset, so a pod placed onto a
+	// member cluster always goes through the synchronous path below;
+	// local-cluster creates go through the queue unless the caller opts out
+	// with ?sync=true.
+	if chosenCluster == "" && r.URL.Query().Get("sync") != "true" {
+		h.queueCreate(w, r, pod)
+		return
+	}
+
+	createdPod, err := clientset.CoreV1().Pods(h.namespace).Create(r.Context(), pod, metav1.CreateOptions{})
 	if err != nil {
 		handleK8sAPiError(w, err, "failed to create pod")
 		return
@@ -254,9 +612,123 @@ func (h *AEnvPodHandler) createPod(w http.ResponseWriter, r *http.Request) {
 		Success: true,
 		Code:    0,
 		ResponseData: HttpResponseData{
-			ID:     createdPod.Name,
-			Status: string(createdPod.Status.Phase),
-			IP:     createdPod.Status.PodIP,
+			ID:      createdPod.Name,
+			Status:  string(createdPod.Status.Phase),
+			IP:      createdPod.Status.PodIP,
+			Cluster: chosenCluster,
+		},
+	}
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		klog.Errorf("failed to encode response: %v", err)
+	}
+}
+
+// queueCreate hands pod off to the retry queue and responds 202 Accepted
+// with a task ID, instead of waiting for the apiserver call to complete.
+func (h *AEnvPodHandler) queueCreate(w http.ResponseWriter, r *http.Request, pod *corev1.Pod) {
+	task := h.retry.Enqueue(PodOpCreate, pod.Name, pod)
+	writeTaskResponse(w, task)
+}
+
+// writeTaskResponse responds 202 Accepted describing task's initial state.
+func writeTaskResponse(w http.ResponseWriter, task *PodOpTask) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	res := &HttpTaskResponse{
+		Success: true,
+		Code:    0,
+		ResponseData: HttpTaskResponseData{
+			ID:      task.ID,
+			Op:      task.Op,
+			PodName: task.PodName,
+			Status:  task.Status,
+		},
+	}
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		klog.Errorf("failed to encode response: %v", err)
+	}
+}
+
+// getTask reports a queued create/delete call's current status, backing
+// GET /tasks/{id}.
+func (h *AEnvPodHandler) getTask(taskID string, w http.ResponseWriter, r *http.Request) {
+	if taskID == "" {
+		http.Error(w, "missing task id", http.StatusBadRequest)
+		return
+	}
+	task, ok := h.retry.GetTask(taskID)
+	if !ok {
+		http.Error(w, fmt.Sprintf("task %s not found", taskID), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	res := &HttpTaskResponse{
+		Success: true,
+		Code:    0,
+		ResponseData: HttpTaskResponseData{
+			ID:      task.ID,
+			Op:      task.Op,
+			PodName: task.PodName,
+			Status:  task.Status,
+		},
+	}
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		klog.Errorf("failed to encode response: %v", err)
+	}
+}
+
+// createInstance creates an AEnvInstance CR carrying the same fields
+// createPod used to marshal straight into a Pod, handing rendering and
+// lifecycle management off to controller.InstanceReconciler. The pod itself
+// doesn't exist yet when this returns, so the response reports the instance
+// as Pending rather than echoing a pod phase/IP.
+func (h *AEnvPodHandler) createInstance(w http.ResponseWriter, r *http.Request, aenvHubEnv *model.AEnvHubEnv, chosenCluster string) {
+	artifacts := make([]aenvv1alpha1.Artifact, 0, len(aenvHubEnv.Artifacts))
+	for _, a := range aenvHubEnv.Artifacts {
+		artifacts = append(artifacts, aenvv1alpha1.Artifact{ID: a.Id, Type: a.Type, Content: a.Content})
+	}
+	deployConfig := make(map[string]string, len(aenvHubEnv.DeployConfig))
+	for k, v := range aenvHubEnv.DeployConfig {
+		if s, ok := v.(string); ok {
+			deployConfig[k] = s
+		}
+	}
+	ttl := ""
+	if v, ok := aenvHubEnv.DeployConfig["ttl"].(string); ok {
+		ttl = v
+	}
+
+	instance := &aenvv1alpha1.AEnvInstance{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s", aenvHubEnv.Name, RandString(6)),
+			Namespace: h.namespace,
+		},
+		Spec: aenvv1alpha1.AEnvInstanceSpec{
+			EnvName:      aenvHubEnv.Name,
+			Artifacts:    artifacts,
+			DeployConfig: deployConfig,
+			TTL:          ttl,
+			ClusterName:  chosenCluster,
+		},
+	}
+
+	if err := h.instanceClient.Create(r.Context(), instance); err != nil {
+		handleK8sAPiError(w, err, "failed to create aenvinstance")
+		return
+	}
+	klog.Infof("created aenvinstance %s/%s successfully", h.namespace, instance.Name)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	res := &HttpResponse{
+		Success: true,
+		Code:    0,
+		ResponseData: HttpResponseData{
+			ID:      instance.Name,
+			Status:  model.EnvStatusNameByStatus(model.EnvStatusPending),
+			Cluster: chosenCluster,
 		},
 	}
 	if err := json.NewEncoder(w).Encode(res); err != nil {
@@ -282,11 +754,24 @@ func (h *AEnvPodHandler) getPod(podName string, w http.ResponseWriter, r *http.R
 		return
 	}
 
-	// Get Pod from cache
-	pod, err := h.podCache.GetPod(h.namespace, podName)
+	clusterName := r.URL.Query().Get("cluster")
+
+	var pod *corev1.Pod
+	var err error
+	if clusterName == "" {
+		// Get Pod from cache (local cluster only)
+		pod, err = h.cacheManager.GetPod(h.namespace, podName)
+	} else {
+		err = fmt.Errorf("bypass cache for explicit cluster %s", clusterName)
+	}
 	if err != nil {
-		// Fall back to K8s API
-		pod, err = h.clientset.CoreV1().Pods(h.namespace).Get(r.Context(), podName, metav1.GetOptions{})
+		// Fall back to the K8s API of the requested (or local) cluster
+		clientset, cErr := h.clientsetForCluster(clusterName)
+		if cErr != nil {
+			http.Error(w, fmt.Sprintf("failed to resolve cluster: %v", cErr), http.StatusBadRequest)
+			return
+		}
+		pod, err = clientset.CoreV1().Pods(h.namespace).Get(r.Context(), podName, metav1.GetOptions{})
 		if err != nil {
 			handleK8sAPiError(w, err, "failed to get pod")
 			return
@@ -300,10 +785,11 @@ func (h *AEnvPodHandler) getPod(podName string, w http.ResponseWriter, r *http.R
 		Success: true,
 		Code:    0,
 		ResponseData: HttpResponseData{
-			ID:     pod.Name,
-			TTL:    pod.Labels[constants.AENV_TTL],
-			Status: string(pod.Status.Phase),
-			IP:     pod.Status.PodIP,
+			ID:      pod.Name,
+			TTL:     pod.Labels[constants.AENV_TTL],
+			Status:  string(pod.Status.Phase),
+			IP:      pod.Status.PodIP,
+			Cluster: pod.Labels[clusterLabel],
 		},
 	}
 
@@ -312,6 +798,52 @@ func (h *AEnvPodHandler) getPod(podName string, w http.ResponseWriter, r *http.R
 	}
 }
 
+// watchPod streams podName's state transitions (Running, Failed, Deleted,
+// TTLExpired) as Server-Sent Events, so callers don't have to poll getPod.
+// Each event is one "data: <json-encoded PodEvent>\n\n" line; the stream ends
+// when the client disconnects.
+func (h *AEnvPodHandler) watchPod(podName string, w http.ResponseWriter, r *http.Request) {
+	if podName == "" {
+		http.Error(w, "missing pod name", http.StatusBadRequest)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	events, unsubscribe := h.cacheManager.Events().Subscribe(podName)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			payload, err := json.Marshal(event)
+			if err != nil {
+				klog.Errorf("failed to encode pod event: %v", err)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", payload); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
 /*
 *
 
@@ -333,20 +865,35 @@ func (h *AEnvPodHandler) getPod(podName string, w http.ResponseWriter, r *http.R
 	}
 */
 func (h *AEnvPodHandler) listPod(w http.ResponseWriter, r *http.Request) {
-	// query param:?filter=expired
+	// query param:?filter=expired|expiring-soon, and for expiring-soon, ?within=<duration>
 	filterMark := r.URL.Query().Get("filter")
 
 	var podList []*corev1.Pod
 	var err error
 	if filterMark == "expired" {
-		podList, err = h.podCache.ListExpiredPods(h.namespace)
+		podList, err = h.cacheManager.ListExpiredPods(r.Context(), h.namespace, 0)
 		if err != nil {
 			klog.Errorf("failed to list expired pods: %v", err)
 			return
 		}
+	} else if filterMark == "expiring-soon" {
+		within := defaultExpiringSoonWindow
+		if raw := r.URL.Query().Get("within"); raw != "" {
+			if parsed, parseErr := time.ParseDuration(raw); parseErr == nil {
+				within = parsed
+			}
+		}
+		for _, key := range h.ttlGC.ExpiringSoon(within) {
+			namespace, name := splitGCKey(key)
+			pod, getErr := h.cacheManager.GetPod(namespace, name)
+			if getErr != nil {
+				continue
+			}
+			podList = append(podList, pod)
+		}
 	} else {
 		// Get Pod from cache
-		podList, err = h.podCache.ListPodsByNamespace(h.namespace)
+		podList, err = h.cacheManager.ListPodsByNamespace(h.namespace)
 		if err != nil {
 			klog.Errorf("failed to list pods: %v", err)
 			return
@@ -366,6 +913,7 @@ func (h *AEnvPodHandler) listPod(w http.ResponseWriter, r *http.Request) {
 			Status:    string(pod.Status.Phase),
 			CreatedAt: pod.CreationTimestamp.Time,
 			TTL:       pod.Labels[constants.AENV_TTL],
+			Cluster:   pod.Labels[clusterLabel],
 		})
 	}
 
@@ -389,8 +937,21 @@ func (h *AEnvPodHandler) deletePod(podName string, w http.ResponseWriter, r *htt
 		return
 	}
 
+	clusterName := r.URL.Query().Get("cluster")
+	if clusterName == "" && r.URL.Query().Get("sync") != "true" {
+		task := h.retry.Enqueue(PodOpDelete, podName, nil)
+		writeTaskResponse(w, task)
+		return
+	}
+
+	clientset, err := h.clientsetForCluster(clusterName)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to resolve cluster: %v", err), http.StatusBadRequest)
+		return
+	}
+
 	deleteOptions := metav1.DeleteOptions{}
-	err := h.clientset.CoreV1().Pods(h.namespace).Delete(r.Context(), podName, deleteOptions)
+	err = clientset.CoreV1().Pods(h.namespace).Delete(r.Context(), podName, deleteOptions)
 	if err != nil {
 		handleK8sAPiError(w, err, "failed to delete Pod")
 		return
@@ -410,6 +971,67 @@ func (h *AEnvPodHandler) deletePod(podName string, w http.ResponseWriter, r *htt
 	}
 }
 
+// prunePods runs the TTL reaper on demand, the same sweep the background
+// PodReaper performs on its timer.
+/**
+POST /pods/prune?dryRun=true&ttlOverride=10m
+{
+  "pods": [{"name": "leopard-linux-v1-7q8y9v0a1b2c", "deleted": true}],
+  "reclaimedCpu": "1",
+  "reclaimedMemory": "2Gi"
+}
+*/
+func (h *AEnvPodHandler) prunePods(w http.ResponseWriter, r *http.Request) {
+	opts := PruneOptions{
+		DryRun: r.URL.Query().Get("dryRun") == "true",
+	}
+	if v := r.URL.Query().Get("ttlOverride"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid ttlOverride: %v", err), http.StatusBadRequest)
+			return
+		}
+		opts.TTLOverride = d
+	}
+
+	result, err := h.reaper.Prune(r.Context(), opts)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("prune failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		klog.Errorf("failed to encode response: %v", err)
+	}
+}
+
+// HttpErrorResponse is the structured body written for ResourcePolicy
+// violations, giving callers a stable Code to branch on instead of parsing
+// freeform message text.
+type HttpErrorResponse struct {
+	Success bool   `json:"success"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// writeResourcePolicyError responds 400 Bad Request with err's structured
+// Code/Message when it's a *ResourcePolicyError, falling back to plain text
+// for any other error a custom ResourcePolicy might return.
+func writeResourcePolicyError(w http.ResponseWriter, err error) {
+	policyErr, ok := err.(*ResourcePolicyError)
+	if !ok {
+		http.Error(w, fmt.Sprintf("resource policy rejected request: %v", err), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	if err := json.NewEncoder(w).Encode(&HttpErrorResponse{Code: policyErr.Code, Message: policyErr.Message}); err != nil {
+		klog.Errorf("failed to encode response: %v", err)
+	}
+}
+
 // handleK8sAPiError handles Kubernetes API errors
 func handleK8sAPiError(w http.ResponseWriter, err error, action string) {
 	if statusErr, ok := err.(*errors.StatusError); ok {
@@ -0,0 +1,338 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aenvhub_http_server
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/klog"
+)
+
+// AEnvExecHandler exposes interactive shells on top of the EnvInstance pod
+// pool, mirroring Podman's compat container endpoints: create an exec (POST
+// /envInstances/{id}/exec), start its stream (POST /exec/{id}/start),
+// resize its TTY (POST /exec/{id}/resize), or attach directly to PID 1 (GET
+// /envInstances/{id}/attach). It resolves the target pod and container from
+// AEnvCacheManager's env-label index instead of calling the API server.
+type AEnvExecHandler struct {
+	clientset    kubernetes.Interface
+	config       *rest.Config
+	cacheManager *AEnvCacheManager
+	sessions     *ExecManager
+}
+
+// NewAEnvExecHandler builds an exec handler sharing h's clientset, REST
+// config and cache manager, so exec sessions resolve pods the same way the
+// pod-lifecycle endpoints do.
+func NewAEnvExecHandler(h *AEnvPodHandler) *AEnvExecHandler {
+	return &AEnvExecHandler{
+		clientset:    h.clientset,
+		config:       h.config,
+		cacheManager: h.cacheManager,
+		sessions:     NewExecManager(),
+	}
+}
+
+// ExecCreateRequest is the POST /envInstances/{id}/exec body.
+type ExecCreateRequest struct {
+	Cmd       []string `json:"cmd"`
+	Container string   `json:"container,omitempty"`
+	TTY       bool     `json:"tty"`
+}
+
+// ExecCreateResponse is returned by POST /envInstances/{id}/exec.
+type ExecCreateResponse struct {
+	ID string `json:"id"`
+}
+
+// ServeHTTP routes the exec/attach endpoints. Unlike AEnvPodHandler, it is
+// mounted on two prefixes ("/envInstances/" and "/exec/"), so it matches on
+// path shape rather than a single fixed leading segment.
+func (h *AEnvExecHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(r.URL.Path, "/")
+	klog.Infof("access URL path %s, method %s, host %s", r.URL.Path, r.Method, r.Host)
+
+	switch {
+	case r.Method == http.MethodPost && len(parts) == 4 && parts[1] == "envInstances" && parts[3] == "exec":
+		h.createExec(w, r, parts[2])
+	case r.Method == http.MethodGet && len(parts) == 4 && parts[1] == "envInstances" && parts[3] == "attach":
+		h.attach(w, r, parts[2])
+	case r.Method == http.MethodPost && len(parts) == 4 && parts[1] == "exec" && parts[3] == "start":
+		h.startExec(w, r, parts[2])
+	case r.Method == http.MethodPost && len(parts) == 4 && parts[1] == "exec" && parts[3] == "resize":
+		h.resizeExec(w, r, parts[2])
+	default:
+		http.Error(w, "http method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// resolvePod finds the pod backing envID via the cache manager's env-label
+// index and picks container (or the pod's first container when unset).
+func (h *AEnvExecHandler) resolvePod(envID, container string) (*corev1.Pod, string, error) {
+	pods, err := h.cacheManager.ListPodsByEnv(envID)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to resolve env %s: %v", envID, err)
+	}
+	if len(pods) == 0 {
+		return nil, "", fmt.Errorf("no running pod found for env %s", envID)
+	}
+	pod := pods[0]
+	if container == "" {
+		if len(pod.Spec.Containers) == 0 {
+			return nil, "", fmt.Errorf("pod %s has no containers", pod.Name)
+		}
+		container = pod.Spec.Containers[0].Name
+	}
+	return pod, container, nil
+}
+
+// createExec registers a session for envID's pod and returns its ID; the
+// actual command only runs once the client calls POST /exec/{id}/start.
+func (h *AEnvExecHandler) createExec(w http.ResponseWriter, r *http.Request, envID string) {
+	var req ExecCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if len(req.Cmd) == 0 {
+		http.Error(w, "cmd is required", http.StatusBadRequest)
+		return
+	}
+
+	pod, container, err := h.resolvePod(envID, req.Container)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	sess := h.sessions.Create(pod.Namespace, pod.Name, container, req.Cmd, req.TTY)
+	klog.Infof("created exec session %s for env %s (pod %s/%s, container %s)", sess.id, envID, pod.Namespace, pod.Name, container)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(ExecCreateResponse{ID: sess.id}); err != nil {
+		klog.Errorf("failed to encode response: %v", err)
+	}
+}
+
+// startExec hijacks the HTTP connection and streams stdin/stdout/stderr
+// between the caller and the pod's exec session via the kubelet, until
+// either side closes the connection.
+func (h *AEnvExecHandler) startExec(w http.ResponseWriter, r *http.Request, id string) {
+	sess, err := h.sessions.Get(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	defer h.sessions.Delete(id)
+	defer sess.resize.Close()
+
+	conn, err := hijack(w)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer conn.Close()
+
+	executor, err := remotecommand.NewSPDYExecutor(h.config, "POST", execURL(h.clientset, sess))
+	if err != nil {
+		klog.Errorf("exec %s: failed to build executor: %v", id, err)
+		return
+	}
+
+	opts := remotecommand.StreamOptions{
+		Stdin:             conn,
+		Tty:               sess.tty,
+		TerminalSizeQueue: sess.resize,
+	}
+	opts.Stdout, opts.Stderr = streamWriters(conn, r.URL.Query().Get("stream"), sess.tty)
+
+	if err := executor.Stream(opts); err != nil {
+		klog.Errorf("exec %s: stream ended with error: %v", id, err)
+	}
+}
+
+// resizeExec forwards a TTY size change from ?h=&w= onto the session's
+// resize queue, which the in-flight executor.Stream call is reading from.
+func (h *AEnvExecHandler) resizeExec(w http.ResponseWriter, r *http.Request, id string) {
+	sess, err := h.sessions.Get(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	height, err := parseUintQuery(r, "h")
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid h: %v", err), http.StatusBadRequest)
+		return
+	}
+	width, err := parseUintQuery(r, "w")
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid w: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	sess.resize.Resize(height, width)
+	w.WriteHeader(http.StatusOK)
+}
+
+// attach streams directly to a pod's PID 1 without a create/start split,
+// for clients that just want a shell on the main container.
+func (h *AEnvExecHandler) attach(w http.ResponseWriter, r *http.Request, envID string) {
+	pod, container, err := h.resolvePod(envID, r.URL.Query().Get("container"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	tty := r.URL.Query().Get("tty") == "true"
+
+	conn, err := hijack(w)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer conn.Close()
+
+	req := h.clientset.CoreV1().RESTClient().Post().
+		Namespace(pod.Namespace).
+		Resource("pods").
+		Name(pod.Name).
+		SubResource("attach").
+		VersionedParams(&corev1.PodAttachOptions{
+			Container: container,
+			Stdin:     true,
+			Stdout:    true,
+			Stderr:    !tty,
+			TTY:       tty,
+		}, scheme.ParameterCodec)
+
+	executor, err := remotecommand.NewSPDYExecutor(h.config, "POST", req.URL())
+	if err != nil {
+		klog.Errorf("attach %s/%s: failed to build executor: %v", pod.Name, container, err)
+		return
+	}
+
+	opts := remotecommand.StreamOptions{Stdin: conn, Tty: tty}
+	opts.Stdout, opts.Stderr = streamWriters(conn, r.URL.Query().Get("stream"), tty)
+
+	if err := executor.Stream(opts); err != nil {
+		klog.Errorf("attach %s/%s: stream ended with error: %v", pod.Name, container, err)
+	}
+}
+
+// execURL builds the exec subresource request URL for sess.
+func execURL(clientset kubernetes.Interface, sess *execSession) *url.URL {
+	req := clientset.CoreV1().RESTClient().Post().
+		Namespace(sess.namespace).
+		Resource("pods").
+		Name(sess.pod).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: sess.container,
+			Command:   sess.command,
+			Stdin:     true,
+			Stdout:    true,
+			Stderr:    !sess.tty,
+			TTY:       sess.tty,
+		}, scheme.ParameterCodec)
+	return req.URL()
+}
+
+// hijack takes over the raw connection behind w for bidirectional
+// streaming, writing a minimal handshake line so the client knows the
+// stream has started.
+func hijack(w http.ResponseWriter) (io.ReadWriteCloser, error) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, fmt.Errorf("streaming not supported by this connection")
+	}
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		return nil, fmt.Errorf("failed to hijack connection: %v", err)
+	}
+	if _, err := conn.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n")); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to write handshake: %v", err)
+	}
+	return conn, nil
+}
+
+// streamWriters returns the stdout/stderr writers for a hijacked
+// connection. A TTY session always combines stdout/stderr onto the raw
+// connection (stderr is nil, matching remotecommand's TTY contract).
+// Non-TTY sessions default to Docker-style demuxed frames (an 8-byte
+// header per write: 1-byte stream type + 4-byte big-endian length) unless
+// ?stream=raw asks for the two streams interleaved directly on the wire.
+func streamWriters(conn io.Writer, streamMode string, tty bool) (stdout, stderr io.Writer) {
+	if tty {
+		return conn, nil
+	}
+	if streamMode == "raw" {
+		return conn, conn
+	}
+	var mu sync.Mutex
+	return &demuxWriter{conn: conn, mu: &mu, streamType: 1}, &demuxWriter{conn: conn, mu: &mu, streamType: 2}
+}
+
+// demuxWriter prefixes every write with an 8-byte frame header identifying
+// which stream (1=stdout, 2=stderr) it belongs to, so a single hijacked
+// connection can multiplex both without interleaving corrupting output.
+type demuxWriter struct {
+	conn       io.Writer
+	mu         *sync.Mutex
+	streamType byte
+}
+
+func (f *demuxWriter) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	header := make([]byte, 8)
+	header[0] = f.streamType
+	binary.BigEndian.PutUint32(header[4:], uint32(len(p)))
+	if _, err := f.conn.Write(header); err != nil {
+		return 0, err
+	}
+	return f.conn.Write(p)
+}
+
+func parseUintQuery(r *http.Request, name string) (uint16, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return 0, fmt.Errorf("missing %s", name)
+	}
+	n, err := strconv.ParseUint(v, 10, 16)
+	if err != nil {
+		return 0, err
+	}
+	return uint16(n), nil
+}
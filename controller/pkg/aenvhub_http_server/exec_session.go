@@ -0,0 +1,132 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aenvhub_http_server
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// execSession is a created-but-not-yet-started exec, mirroring Podman's
+// compat API split between POST .../exec (create) and POST /exec/{id}/start
+// (attach the actual stream).
+type execSession struct {
+	id        string
+	namespace string
+	pod       string
+	container string
+	command   []string
+	tty       bool
+	createdAt time.Time
+
+	resize *resizeQueue
+}
+
+// resizeQueue adapts a channel of TTY sizes into the
+// remotecommand.TerminalSizeQueue the SPDY executor expects, fed by the
+// POST /exec/{id}/resize?h=&w= endpoint.
+type resizeQueue struct {
+	sizes chan remotecommand.TerminalSize
+}
+
+func newResizeQueue() *resizeQueue {
+	// Buffered so Resize doesn't block on a session that hasn't started
+	// streaming (or whose reader is momentarily busy) yet.
+	return &resizeQueue{sizes: make(chan remotecommand.TerminalSize, 4)}
+}
+
+// Next implements remotecommand.TerminalSizeQueue.
+func (q *resizeQueue) Next() *remotecommand.TerminalSize {
+	size, ok := <-q.sizes
+	if !ok {
+		return nil
+	}
+	return &size
+}
+
+// Resize enqueues a new TTY size for the running exec's resize channel.
+func (q *resizeQueue) Resize(height, width uint16) {
+	size := remotecommand.TerminalSize{Height: height, Width: width}
+	for {
+		select {
+		case q.sizes <- size:
+			return
+		default:
+		}
+		// Drop stale resize events rather than blocking the HTTP handler;
+		// only the most recent size matters once a newer one is queued.
+		select {
+		case <-q.sizes:
+		default:
+		}
+	}
+}
+
+func (q *resizeQueue) Close() {
+	close(q.sizes)
+}
+
+// ExecManager tracks created-but-not-started exec sessions, keyed by ID.
+type ExecManager struct {
+	mu       sync.Mutex
+	sessions map[string]*execSession
+}
+
+// NewExecManager creates an empty session manager.
+func NewExecManager() *ExecManager {
+	return &ExecManager{sessions: make(map[string]*execSession)}
+}
+
+// Create registers a new exec session for pod/container and returns its ID.
+func (m *ExecManager) Create(namespace, pod, container string, command []string, tty bool) *execSession {
+	sess := &execSession{
+		id:        RandString(16),
+		namespace: namespace,
+		pod:       pod,
+		container: container,
+		command:   command,
+		tty:       tty,
+		createdAt: time.Now(),
+		resize:    newResizeQueue(),
+	}
+
+	m.mu.Lock()
+	m.sessions[sess.id] = sess
+	m.mu.Unlock()
+	return sess
+}
+
+// Get looks up a session by ID.
+func (m *ExecManager) Get(id string) (*execSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sess, ok := m.sessions[id]
+	if !ok {
+		return nil, fmt.Errorf("exec session %s not found", id)
+	}
+	return sess, nil
+}
+
+// Delete removes a session once its stream has ended.
+func (m *ExecManager) Delete(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, id)
+}
@@ -0,0 +1,304 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aenvhub_http_server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"controller/pkg/constants"
+	"controller/pkg/model"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+)
+
+// manifestAllowedKinds is the set of document kinds POST /env-instance/manifest
+// accepts, the minimum a single env instance commonly needs beyond the Pod
+// itself: sidecar config, init secrets, and a per-instance Service.
+var manifestAllowedKinds = map[string]bool{
+	"Pod":       true,
+	"ConfigMap": true,
+	"Secret":    true,
+	"Service":   true,
+}
+
+// ManifestRequest is the body of POST /env-instance/manifest: a multi-document
+// Kubernetes manifest (exactly one Pod, plus optional ConfigMaps, Secrets and
+// a Service) rendered into one isolated env instance. Artifacts/DeployConfig
+// are the same envelope createPod accepts and drive the Pod's image and
+// resource limits via MergePodImage/applyConfig, so the manifest itself only
+// needs to describe shape (volumes, ports, env files) instead of duplicating
+// image/resource config that already has a home in DeployConfig.
+type ManifestRequest struct {
+	Manifest     string                 `json:"manifest"`
+	Artifacts    []model.Artifact       `json:"artifacts,omitempty"`
+	DeployConfig map[string]interface{} `json:"deployConfig,omitempty"`
+}
+
+// ManifestResponse aggregates what one POST /env-instance/manifest call
+// created. PodIP is typically empty in the response, since the apiserver
+// hasn't scheduled the pod yet by the time this handler returns; ClusterIP is
+// allocated synchronously on Service creation so it's always populated when a
+// Service document was supplied.
+type ManifestResponse struct {
+	Pod        string   `json:"pod"`
+	PodIP      string   `json:"podIp,omitempty"`
+	ConfigMaps []string `json:"configMaps,omitempty"`
+	Secrets    []string `json:"secrets,omitempty"`
+	Service    string   `json:"service,omitempty"`
+	ClusterIP  string   `json:"clusterIp,omitempty"`
+}
+
+// applyManifest handles POST /env-instance/manifest: a "play kube" style
+// bulk-create of one env instance's Pod plus its supporting ConfigMaps,
+// Secrets and Service, analogous to the replica-oriented POST /envs/play but
+// scoped to a single instance with Kubernetes owner references doing cleanup
+// instead of a separate play/down call.
+func (h *AEnvPodHandler) applyManifest(w http.ResponseWriter, r *http.Request) {
+	var req ManifestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	docs, err := splitManifest(req.Manifest)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid manifest: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	var pod *corev1.Pod
+	var configMaps []*corev1.ConfigMap
+	var secrets []*corev1.Secret
+	var service *corev1.Service
+
+	for _, doc := range docs {
+		var typeMeta metav1.TypeMeta
+		if err := json.Unmarshal(doc, &typeMeta); err != nil {
+			http.Error(w, fmt.Sprintf("invalid manifest document: %v", err), http.StatusBadRequest)
+			return
+		}
+		if !manifestAllowedKinds[typeMeta.Kind] {
+			http.Error(w, fmt.Sprintf("unsupported manifest kind %q, only Pod/ConfigMap/Secret/Service are supported", typeMeta.Kind), http.StatusBadRequest)
+			return
+		}
+
+		switch typeMeta.Kind {
+		case "Pod":
+			if pod != nil {
+				http.Error(w, "manifest must contain exactly one Pod document", http.StatusBadRequest)
+				return
+			}
+			var p corev1.Pod
+			if err := json.Unmarshal(doc, &p); err != nil {
+				http.Error(w, fmt.Sprintf("failed to decode pod: %v", err), http.StatusBadRequest)
+				return
+			}
+			pod = &p
+		case "ConfigMap":
+			var cm corev1.ConfigMap
+			if err := json.Unmarshal(doc, &cm); err != nil {
+				http.Error(w, fmt.Sprintf("failed to decode configmap: %v", err), http.StatusBadRequest)
+				return
+			}
+			configMaps = append(configMaps, &cm)
+		case "Secret":
+			var s corev1.Secret
+			if err := json.Unmarshal(doc, &s); err != nil {
+				http.Error(w, fmt.Sprintf("failed to decode secret: %v", err), http.StatusBadRequest)
+				return
+			}
+			secrets = append(secrets, &s)
+		case "Service":
+			if service != nil {
+				http.Error(w, "manifest must contain at most one Service document", http.StatusBadRequest)
+				return
+			}
+			var svc corev1.Service
+			if err := json.Unmarshal(doc, &svc); err != nil {
+				http.Error(w, fmt.Sprintf("failed to decode service: %v", err), http.StatusBadRequest)
+				return
+			}
+			service = &svc
+		}
+	}
+	if pod == nil {
+		http.Error(w, "manifest must contain exactly one Pod document", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.createManifestInstance(r.Context(), pod, configMaps, secrets, service, &req)
+	if err != nil {
+		handleK8sAPiError(w, err, "apply manifest")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		klog.Errorf("failed to encode response: %v", err)
+	}
+}
+
+// createManifestInstance rewrites every document's name with a shared
+// RandString(6) suffix to preserve isolation between instances rendered from
+// the same manifest, creates the Pod first so the rest can carry it as their
+// owner reference, and aggregates the result.
+func (h *AEnvPodHandler) createManifestInstance(ctx context.Context, pod *corev1.Pod, configMaps []*corev1.ConfigMap, secrets []*corev1.Secret, service *corev1.Service, req *ManifestRequest) (*ManifestResponse, error) {
+	suffix := RandString(6)
+	nameMap := make(map[string]string)
+	for _, cm := range configMaps {
+		nameMap[cm.Name] = fmt.Sprintf("%s-%s", cm.Name, suffix)
+	}
+	for _, s := range secrets {
+		nameMap[s.Name] = fmt.Sprintf("%s-%s", s.Name, suffix)
+	}
+
+	podName := pod.Name
+	if podName == "" {
+		podName = "env-instance"
+	}
+	pod.Name = fmt.Sprintf("%s-%s", podName, suffix)
+	pod.Namespace = h.namespace
+	pod.ResourceVersion = ""
+	pod.UID = ""
+	rewriteManifestRefs(pod, nameMap)
+
+	if len(req.Artifacts) > 0 || len(req.DeployConfig) > 0 {
+		MergePodImage(pod, &model.AEnvHubEnv{Artifacts: req.Artifacts, DeployConfig: req.DeployConfig})
+	}
+	if ttlValue, ok := req.DeployConfig["ttl"].(string); ok && ttlValue != "" {
+		if pod.Labels == nil {
+			pod.Labels = make(map[string]string)
+		}
+		pod.Labels[constants.AENV_TTL] = ttlValue
+		pod.Finalizers = append(pod.Finalizers, TTLFinalizer)
+	}
+
+	createdPod, err := h.clientset.CoreV1().Pods(h.namespace).Create(ctx, pod, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pod %s: %v", pod.Name, err)
+	}
+	ownerRef := metav1.NewControllerRef(createdPod, corev1.SchemeGroupVersion.WithKind("Pod"))
+
+	resp := &ManifestResponse{Pod: createdPod.Name, PodIP: createdPod.Status.PodIP}
+
+	for _, cm := range configMaps {
+		cm.Name = nameMap[cm.Name]
+		cm.Namespace = h.namespace
+		cm.ResourceVersion = ""
+		cm.UID = ""
+		cm.OwnerReferences = append(cm.OwnerReferences, *ownerRef)
+		created, err := h.clientset.CoreV1().ConfigMaps(h.namespace).Create(ctx, cm, metav1.CreateOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create configmap %s: %v", cm.Name, err)
+		}
+		resp.ConfigMaps = append(resp.ConfigMaps, created.Name)
+	}
+
+	for _, s := range secrets {
+		s.Name = nameMap[s.Name]
+		s.Namespace = h.namespace
+		s.ResourceVersion = ""
+		s.UID = ""
+		s.OwnerReferences = append(s.OwnerReferences, *ownerRef)
+		created, err := h.clientset.CoreV1().Secrets(h.namespace).Create(ctx, s, metav1.CreateOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create secret %s: %v", s.Name, err)
+		}
+		resp.Secrets = append(resp.Secrets, created.Name)
+	}
+
+	if service != nil {
+		service.Name = fmt.Sprintf("%s-%s", service.Name, suffix)
+		service.Namespace = h.namespace
+		service.ResourceVersion = ""
+		service.UID = ""
+		service.OwnerReferences = append(service.OwnerReferences, *ownerRef)
+		created, err := h.clientset.CoreV1().Services(h.namespace).Create(ctx, service, metav1.CreateOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create service %s: %v", service.Name, err)
+		}
+		resp.Service = created.Name
+		resp.ClusterIP = created.Spec.ClusterIP
+	}
+
+	return resp, nil
+}
+
+// rewriteManifestRefs points pod's ConfigMap/Secret volume and env references
+// at their renamed counterparts in nameMap, so renaming a ConfigMap/Secret for
+// isolation doesn't break the Pod document that mounts it by its original name.
+func rewriteManifestRefs(pod *corev1.Pod, nameMap map[string]string) {
+	for i := range pod.Spec.Volumes {
+		v := &pod.Spec.Volumes[i]
+		if v.ConfigMap != nil {
+			if renamed, ok := nameMap[v.ConfigMap.Name]; ok {
+				v.ConfigMap.Name = renamed
+			}
+		}
+		if v.Secret != nil {
+			if renamed, ok := nameMap[v.Secret.SecretName]; ok {
+				v.Secret.SecretName = renamed
+			}
+		}
+	}
+
+	for i := range pod.Spec.Containers {
+		rewriteContainerRefs(&pod.Spec.Containers[i], nameMap)
+	}
+	for i := range pod.Spec.InitContainers {
+		rewriteContainerRefs(&pod.Spec.InitContainers[i], nameMap)
+	}
+}
+
+func rewriteContainerRefs(container *corev1.Container, nameMap map[string]string) {
+	for i := range container.EnvFrom {
+		ef := &container.EnvFrom[i]
+		if ef.ConfigMapRef != nil {
+			if renamed, ok := nameMap[ef.ConfigMapRef.Name]; ok {
+				ef.ConfigMapRef.Name = renamed
+			}
+		}
+		if ef.SecretRef != nil {
+			if renamed, ok := nameMap[ef.SecretRef.Name]; ok {
+				ef.SecretRef.Name = renamed
+			}
+		}
+	}
+	for i := range container.Env {
+		valueFrom := container.Env[i].ValueFrom
+		if valueFrom == nil {
+			continue
+		}
+		if valueFrom.ConfigMapKeyRef != nil {
+			if renamed, ok := nameMap[valueFrom.ConfigMapKeyRef.Name]; ok {
+				valueFrom.ConfigMapKeyRef.Name = renamed
+			}
+		}
+		if valueFrom.SecretKeyRef != nil {
+			if renamed, ok := nameMap[valueFrom.SecretKeyRef.Name]; ok {
+				valueFrom.SecretKeyRef.Name = renamed
+			}
+		}
+	}
+}
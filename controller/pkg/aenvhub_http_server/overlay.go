@@ -0,0 +1,175 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aenvhub_http_server
+
+import (
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+)
+
+// Resource bounds shared by MergePod and overlay validation; these are the
+// same figures EnvCreateRequest's binding tags describe.
+const (
+	minMemoryMiB             = 256
+	maxMemoryMiB             = 8192
+	minEphemeralStorageBytes = 1 * 1024 * 1024 * 1024  // 1GiB
+	maxEphemeralStorageBytes = 50 * 1024 * 1024 * 1024 // 50GiB
+)
+
+// ContainerOverlay customizes one named container within an EnvOverlay.
+// Name matches the patch-merge-key corev1.Pod's OpenAPI schema uses for
+// Containers, so StrategicMergePatch updates that container in place instead
+// of appending a duplicate.
+type ContainerOverlay struct {
+	Name         string                       `json:"name"`
+	Resources    *corev1.ResourceRequirements `json:"resources,omitempty"`
+	VolumeMounts []corev1.VolumeMount         `json:"volumeMounts,omitempty"`
+}
+
+// EnvOverlay lets a caller customize anything the pod template exposes
+// without forking the template file. Field names match the corev1.PodSpec
+// subset they patch; applying it goes through the same strategic-merge-patch
+// machinery `kubectl apply` uses, so list merges respect each field's
+// patch-merge-key (volumes/containers merge on name, not by replacing the
+// whole list).
+type EnvOverlay struct {
+	NodeSelector     map[string]string             `json:"nodeSelector,omitempty"`
+	Tolerations      []corev1.Toleration           `json:"tolerations,omitempty"`
+	Volumes          []corev1.Volume               `json:"volumes,omitempty"`
+	SecurityContext  *corev1.PodSecurityContext    `json:"securityContext,omitempty"`
+	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+	Containers       []ContainerOverlay            `json:"containers,omitempty"`
+}
+
+// IsEmpty reports whether the overlay has nothing to apply, so callers can
+// skip the marshal/patch/unmarshal round trip for the common case.
+func (o EnvOverlay) IsEmpty() bool {
+	return o.NodeSelector == nil && o.Tolerations == nil && o.Volumes == nil &&
+		o.SecurityContext == nil && o.ImagePullSecrets == nil && o.Containers == nil
+}
+
+// podOverlayFromDeployConfig decodes the "podOverlay" key DeployConfig may
+// carry - the same untyped map "podTemplate"/"ttl"/"imagePrefix" already flow
+// through - into an EnvOverlay, the same shape POST /envs/play accepts as
+// PodOverlay. A missing or nil key decodes to the empty overlay.
+func podOverlayFromDeployConfig(deployConfig map[string]interface{}) (EnvOverlay, error) {
+	raw, ok := deployConfig["podOverlay"]
+	if !ok || raw == nil {
+		return EnvOverlay{}, nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return EnvOverlay{}, fmt.Errorf("failed to marshal podOverlay: %v", err)
+	}
+	var overlay EnvOverlay
+	if err := json.Unmarshal(data, &overlay); err != nil {
+		return EnvOverlay{}, fmt.Errorf("failed to decode podOverlay: %v", err)
+	}
+	return overlay, nil
+}
+
+// ApplyOverlay strategic-merge-patches overlay onto pod using the corev1.Pod
+// schema, then validates the result's per-container memory/ephemeral-storage
+// requests stay within the bounds EnvCreateRequest's binding tags describe.
+func ApplyOverlay(pod *corev1.Pod, overlay EnvOverlay) (*corev1.Pod, error) {
+	if overlay.IsEmpty() {
+		return pod, nil
+	}
+
+	patch := corev1.Pod{
+		Spec: corev1.PodSpec{
+			NodeSelector:     overlay.NodeSelector,
+			Tolerations:      overlay.Tolerations,
+			Volumes:          overlay.Volumes,
+			SecurityContext:  overlay.SecurityContext,
+			ImagePullSecrets: overlay.ImagePullSecrets,
+		},
+	}
+	for _, c := range overlay.Containers {
+		patch.Spec.Containers = append(patch.Spec.Containers, corev1.Container{
+			Name:         c.Name,
+			Resources:    derefResources(c.Resources),
+			VolumeMounts: c.VolumeMounts,
+		})
+	}
+
+	merged, err := strategicMergePod(pod, &patch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply pod overlay: %v", err)
+	}
+
+	if err := validateResourceBounds(merged); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+func derefResources(r *corev1.ResourceRequirements) corev1.ResourceRequirements {
+	if r == nil {
+		return corev1.ResourceRequirements{}
+	}
+	return *r
+}
+
+// strategicMergePod applies patch onto pod via StrategicMergePatch using the
+// corev1.Pod schema, so list fields with a patch-merge-key (env, volumes,
+// containers, ...) merge by key instead of replacing wholesale.
+func strategicMergePod(pod, patch *corev1.Pod) (*corev1.Pod, error) {
+	originalJSON, err := json.Marshal(pod)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal pod: %v", err)
+	}
+	patchJSON, err := json.Marshal(patch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal patch: %v", err)
+	}
+
+	mergedJSON, err := strategicpatch.StrategicMergePatch(originalJSON, patchJSON, corev1.Pod{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to strategic-merge-patch pod: %v", err)
+	}
+
+	var merged corev1.Pod
+	if err := json.Unmarshal(mergedJSON, &merged); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal merged pod: %v", err)
+	}
+	return &merged, nil
+}
+
+// validateResourceBounds rejects a pod whose containers request memory or
+// ephemeral storage outside the bounds EnvCreateRequest's binding tags
+// describe (256MiB-8192MiB memory, 1GiB-50GiB ephemeral storage).
+func validateResourceBounds(pod *corev1.Pod) error {
+	for _, c := range pod.Spec.Containers {
+		if q, ok := c.Resources.Requests[corev1.ResourceMemory]; ok {
+			memoryMiB := q.Value() / (1024 * 1024)
+			if memoryMiB < minMemoryMiB || memoryMiB > maxMemoryMiB {
+				return fmt.Errorf("container %s memory request %dMiB out of bounds [%d, %d]", c.Name, memoryMiB, minMemoryMiB, maxMemoryMiB)
+			}
+		}
+		if q, ok := c.Resources.Requests[corev1.ResourceEphemeralStorage]; ok {
+			bytes := q.Value()
+			if bytes < minEphemeralStorageBytes || bytes > maxEphemeralStorageBytes {
+				return fmt.Errorf("container %s ephemeral storage request %d bytes out of bounds [%d, %d]", c.Name, bytes, minEphemeralStorageBytes, maxEphemeralStorageBytes)
+			}
+		}
+	}
+	return nil
+}
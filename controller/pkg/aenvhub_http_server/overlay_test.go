@@ -0,0 +1,170 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aenvhub_http_server
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestEnvOverlay_IsEmpty(t *testing.T) {
+	if !(EnvOverlay{}).IsEmpty() {
+		t.Fatal("expected the zero-value overlay to be empty")
+	}
+	overlay := EnvOverlay{NodeSelector: map[string]string{"disk": "ssd"}}
+	if overlay.IsEmpty() {
+		t.Fatal("expected an overlay with a NodeSelector set to be non-empty")
+	}
+}
+
+func TestApplyOverlay_EmptyOverlayReturnsPodUnchanged(t *testing.T) {
+	pod := &corev1.Pod{Spec: corev1.PodSpec{NodeSelector: map[string]string{"zone": "a"}}}
+	merged, err := ApplyOverlay(pod, EnvOverlay{})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if merged != pod {
+		t.Fatal("expected ApplyOverlay to return the same pod pointer for an empty overlay")
+	}
+}
+
+func TestApplyOverlay_MergesNodeSelectorAndContainerByName(t *testing.T) {
+	pod := &corev1.Pod{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{Name: "main", Image: "example/app:1"},
+			},
+		},
+	}
+	overlay := EnvOverlay{
+		NodeSelector: map[string]string{"disk": "ssd"},
+		Containers: []ContainerOverlay{
+			{
+				Name: "main",
+				Resources: &corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceMemory: resource.MustParse("512Mi"),
+					},
+				},
+			},
+		},
+	}
+
+	merged, err := ApplyOverlay(pod, overlay)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if merged.Spec.NodeSelector["disk"] != "ssd" {
+		t.Errorf("expected nodeSelector to carry the overlay's value, got %v", merged.Spec.NodeSelector)
+	}
+	if len(merged.Spec.Containers) != 1 {
+		t.Fatalf("expected the overlay to merge into the existing container by name, not append, got %d containers", len(merged.Spec.Containers))
+	}
+	if merged.Spec.Containers[0].Image != "example/app:1" {
+		t.Errorf("expected the original container's image to survive the merge, got %q", merged.Spec.Containers[0].Image)
+	}
+	got := merged.Spec.Containers[0].Resources.Requests[corev1.ResourceMemory]
+	if got.Cmp(resource.MustParse("512Mi")) != 0 {
+		t.Errorf("expected merged memory request 512Mi, got %v", got.String())
+	}
+}
+
+func TestApplyOverlay_RejectsMemoryBelowMinimum(t *testing.T) {
+	pod := &corev1.Pod{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "main"}},
+		},
+	}
+	overlay := EnvOverlay{
+		Containers: []ContainerOverlay{
+			{
+				Name: "main",
+				Resources: &corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceMemory: resource.MustParse("64Mi"),
+					},
+				},
+			},
+		},
+	}
+
+	if _, err := ApplyOverlay(pod, overlay); err == nil {
+		t.Fatal("expected an error for a memory request below the minimum bound")
+	}
+}
+
+func TestApplyOverlay_RejectsEphemeralStorageAboveMaximum(t *testing.T) {
+	pod := &corev1.Pod{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "main"}},
+		},
+	}
+	overlay := EnvOverlay{
+		Containers: []ContainerOverlay{
+			{
+				Name: "main",
+				Resources: &corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceEphemeralStorage: resource.MustParse("100Gi"),
+					},
+				},
+			},
+		},
+	}
+
+	if _, err := ApplyOverlay(pod, overlay); err == nil {
+		t.Fatal("expected an error for an ephemeral storage request above the maximum bound")
+	}
+}
+
+func TestPodOverlayFromDeployConfig_Absent(t *testing.T) {
+	overlay, err := podOverlayFromDeployConfig(map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !overlay.IsEmpty() {
+		t.Fatalf("expected an empty overlay when podOverlay is absent, got %+v", overlay)
+	}
+}
+
+func TestPodOverlayFromDeployConfig_DecodesNestedMap(t *testing.T) {
+	deployConfig := map[string]interface{}{
+		"podOverlay": map[string]interface{}{
+			"nodeSelector": map[string]interface{}{"disk": "ssd"},
+		},
+	}
+	overlay, err := podOverlayFromDeployConfig(deployConfig)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if overlay.NodeSelector["disk"] != "ssd" {
+		t.Errorf("expected decoded nodeSelector to carry \"ssd\", got %v", overlay.NodeSelector)
+	}
+}
+
+func TestPodOverlayFromDeployConfig_InvalidShapeErrors(t *testing.T) {
+	deployConfig := map[string]interface{}{
+		"podOverlay": map[string]interface{}{
+			"nodeSelector": "not-a-map",
+		},
+	}
+	if _, err := podOverlayFromDeployConfig(deployConfig); err == nil {
+		t.Fatal("expected an error when podOverlay doesn't match EnvOverlay's shape")
+	}
+}
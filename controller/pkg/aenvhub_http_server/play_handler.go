@@ -0,0 +1,490 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aenvhub_http_server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"controller/pkg/model"
+	"controller/pkg/parallel"
+	"controller/pkg/storage"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog"
+)
+
+// playIDLabel marks every resource a single POST /envs/play call created, so
+// POST /envs/play/down can tear the whole bundle back down again, the same
+// way `podman play kube --down` uses the pod name it generated at play time.
+const playIDLabel = "aenv-play-id"
+
+const defaultPlayPrewarmSize = 1
+
+// AEnvPlayHandler implements the "play kube" style bulk-create: a multi-doc
+// Kubernetes YAML manifest is translated into one Env (plus a pre-warmed
+// pool of pods) per Pod document, with ConfigMaps/Secrets created alongside.
+type AEnvPlayHandler struct {
+	clientset    kubernetes.Interface
+	cacheManager *AEnvCacheManager
+	namespace    string
+
+	// envStore persists the translated Envs, including the original YAML in
+	// EnvContent.RawManifest, so the play survives process restarts. May be
+	// nil, in which case play still creates the underlying pods but doesn't
+	// persist an Env record for them.
+	envStore storage.EnvStorage
+
+	// pool and prewarmReconciler, when h has SetPrewarmPool configured, let
+	// play fan out each Pod document's replica creation concurrently and
+	// keep the pool topped up afterwards; nil falls back to creating
+	// replicas sequentially and not registering for background reconcile.
+	pool              *parallel.Pool
+	prewarmReconciler *PrewarmReconciler
+
+	mu    sync.Mutex
+	plays map[string][]string // playID -> Env names created by that play
+}
+
+// NewAEnvPlayHandler builds a play handler sharing h's clientset/cache, the
+// same construction pattern NewAEnvExecHandler uses.
+func NewAEnvPlayHandler(h *AEnvPodHandler, envStore storage.EnvStorage) *AEnvPlayHandler {
+	return &AEnvPlayHandler{
+		clientset:         h.clientset,
+		cacheManager:      h.cacheManager,
+		namespace:         h.namespace,
+		envStore:          envStore,
+		pool:              h.pool,
+		prewarmReconciler: h.prewarmReconciler,
+		plays:             make(map[string][]string),
+	}
+}
+
+// ServeHTTP routes POST /envs/play and POST /envs/play/down.
+func (h *AEnvPlayHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 2 || parts[0] != "envs" || parts[1] != "play" {
+		http.Error(w, "Invalid URL path", http.StatusBadRequest)
+		return
+	}
+	klog.Infof("access URL path %s, method %s, host %s", r.URL.Path, r.Method, r.Host)
+
+	switch {
+	case r.Method == http.MethodPost && len(parts) == 2: // /envs/play
+		h.play(w, r)
+	case r.Method == http.MethodPost && len(parts) == 3 && parts[2] == "down": // /envs/play/down
+		h.playDown(w, r)
+	default:
+		http.Error(w, "http method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// PlayRequest is the body of POST /envs/play.
+type PlayRequest struct {
+	// Manifest is a multi-document Kubernetes YAML manifest (Pods,
+	// ConfigMaps, Secrets), the same shape `kubectl apply -f` accepts.
+	Manifest string `json:"manifest"`
+	// Labels are merged onto every Pod document, same as EnvCreateRequest.Labels.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Environs are merged onto every container, same as EnvCreateRequest.Environs.
+	Environs map[string]string `json:"environs,omitempty"`
+	// Memory overrides every Pod document's memory, minimum 256MiB, maximum 8GiB.
+	Memory int `json:"memory,omitempty" binding:"min=256,max=8192"`
+	// EphemeralStorage overrides every Pod document's disk size, minimum 1 GiB, maximum 50 GiB.
+	EphemeralStorage int64 `json:"ephemeralStorage,omitempty" binding:"min=0,max=102400"`
+	// PrewarmSize is the number of ready pod replicas created per Pod document, default 1.
+	PrewarmSize int `json:"prewarmSize,omitempty"`
+	// PodOverlay strategic-merge-patches arbitrary PodSpec fields onto every
+	// Pod document, applied after labels/environs/memory/ephemeralStorage.
+	PodOverlay EnvOverlay `json:"podOverlay,omitempty"`
+}
+
+// PlayedEnv reports the Env one Pod document was translated into and the
+// pool of pods created for it.
+type PlayedEnv struct {
+	Env      string   `json:"env"`
+	PodNames []string `json:"podNames"`
+}
+
+// PlayResponse is the body returned by POST /envs/play.
+type PlayResponse struct {
+	PlayID     string      `json:"playId"`
+	Envs       []PlayedEnv `json:"envs"`
+	ConfigMaps []string    `json:"configMaps,omitempty"`
+	Secrets    []string    `json:"secrets,omitempty"`
+}
+
+// validatePlayRequest enforces the same bounds EnvCreateRequest's binding
+// tags describe, since nothing in this raw net/http server runs gin's
+// validator for us.
+func validatePlayRequest(req *PlayRequest) error {
+	if req.Memory != 0 && (req.Memory < 256 || req.Memory > 8192) {
+		return fmt.Errorf("memory must be between 256 and 8192 MiB, got %d", req.Memory)
+	}
+	if req.EphemeralStorage < 0 || req.EphemeralStorage > 102400 {
+		return fmt.Errorf("ephemeralStorage must be between 0 and 102400, got %d", req.EphemeralStorage)
+	}
+	return nil
+}
+
+func (h *AEnvPlayHandler) play(w http.ResponseWriter, r *http.Request) {
+	var req PlayRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if err := validatePlayRequest(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	docs, err := splitManifest(req.Manifest)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid manifest: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	playID := RandString(10)
+	resp := &PlayResponse{PlayID: playID}
+	var envNames []string
+
+	for _, doc := range docs {
+		var typeMeta metav1.TypeMeta
+		if err := json.Unmarshal(doc, &typeMeta); err != nil {
+			http.Error(w, fmt.Sprintf("invalid manifest document: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		switch typeMeta.Kind {
+		case "Pod":
+			playedEnv, err := h.playPod(r.Context(), doc, &req, playID)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("failed to play pod document: %v", err), http.StatusInternalServerError)
+				return
+			}
+			resp.Envs = append(resp.Envs, *playedEnv)
+			envNames = append(envNames, playedEnv.Env)
+		case "ConfigMap":
+			name, err := h.playConfigMap(r.Context(), doc, playID)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("failed to play configmap document: %v", err), http.StatusInternalServerError)
+				return
+			}
+			resp.ConfigMaps = append(resp.ConfigMaps, name)
+		case "Secret":
+			name, err := h.playSecret(r.Context(), doc, playID)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("failed to play secret document: %v", err), http.StatusInternalServerError)
+				return
+			}
+			resp.Secrets = append(resp.Secrets, name)
+		default:
+			http.Error(w, fmt.Sprintf("unsupported manifest kind %q, only Pod/ConfigMap/Secret are supported", typeMeta.Kind), http.StatusBadRequest)
+			return
+		}
+	}
+
+	h.mu.Lock()
+	h.plays[playID] = envNames
+	h.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		klog.Errorf("failed to encode response: %v", err)
+	}
+}
+
+// playPod translates one Pod document into an Env plus a pre-warmed pool of
+// PrewarmSize real pods, merging req's overrides through the existing
+// MergePod.
+func (h *AEnvPlayHandler) playPod(ctx context.Context, doc []byte, req *PlayRequest, playID string) (*PlayedEnv, error) {
+	var template corev1.Pod
+	if err := json.Unmarshal(doc, &template); err != nil {
+		return nil, fmt.Errorf("failed to decode pod: %v", err)
+	}
+
+	envName := template.Name
+	if envName == "" {
+		envName = fmt.Sprintf("play-%s", RandString(6))
+	}
+
+	image := ""
+	if len(template.Spec.Containers) > 0 {
+		image = template.Spec.Containers[0].Image
+	}
+
+	MergePod(&template, req.Labels, req.Environs, req.Memory, req.EphemeralStorage, image)
+
+	overlaid, err := ApplyOverlay(&template, req.PodOverlay)
+	if err != nil {
+		return nil, err
+	}
+	template = *overlaid
+
+	AddLabelToPod(&template, envName, "")
+	if template.Labels == nil {
+		template.Labels = make(map[string]string)
+	}
+	template.Labels[playIDLabel] = playID
+	template.Namespace = h.namespace
+	template.ResourceVersion = ""
+	template.UID = ""
+
+	if h.envStore != nil {
+		env := model.Env{
+			Name: envName,
+			Content: model.EnvContent{
+				Image:       image,
+				RawManifest: string(doc),
+			},
+			Labels:      req.Labels,
+			Envs:        req.Environs,
+			Memory:      req.Memory,
+			Namespace:   h.namespace,
+			PrewarmSize: req.PrewarmSize,
+		}
+		if err := h.envStore.Create(ctx, envName, env); err != nil {
+			return nil, fmt.Errorf("failed to persist env %s: %v", envName, err)
+		}
+	}
+
+	prewarmSize := req.PrewarmSize
+	if prewarmSize <= 0 {
+		prewarmSize = defaultPlayPrewarmSize
+	}
+
+	played := &PlayedEnv{Env: envName}
+	podNames, err := h.createReplicas(ctx, envName, &template, prewarmSize)
+	if err != nil {
+		return nil, err
+	}
+	played.PodNames = podNames
+
+	if h.prewarmReconciler != nil {
+		h.prewarmReconciler.SetDesired(envName, prewarmSize, &template)
+	}
+	return played, nil
+}
+
+// createReplicas creates count copies of template named "<env>-<rand>". When
+// h has a parallel.Pool configured, replicas are created concurrently
+// instead of one at a time.
+func (h *AEnvPlayHandler) createReplicas(ctx context.Context, env string, template *corev1.Pod, count int) ([]string, error) {
+	if h.pool == nil {
+		names := make([]string, 0, count)
+		for i := 0; i < count; i++ {
+			pod := template.DeepCopy()
+			pod.Name = fmt.Sprintf("%s-%s", env, RandString(6))
+			createdPod, err := h.clientset.CoreV1().Pods(h.namespace).Create(ctx, pod, metav1.CreateOptions{})
+			if err != nil {
+				return nil, fmt.Errorf("failed to create pod %s: %v", pod.Name, err)
+			}
+			names = append(names, createdPod.Name)
+		}
+		return names, nil
+	}
+
+	names := make([]string, count)
+	results := make([]<-chan error, count)
+	for i := 0; i < count; i++ {
+		pod := template.DeepCopy()
+		pod.Name = fmt.Sprintf("%s-%s", env, RandString(6))
+		names[i] = pod.Name
+
+		key := fmt.Sprintf("%s/%s", env, pod.Name)
+		results[i] = parallel.Enqueue[createJob](h.pool, key, func() error {
+			_, err := h.clientset.CoreV1().Pods(h.namespace).Create(ctx, pod, metav1.CreateOptions{})
+			return err
+		})
+	}
+	for i, ch := range results {
+		if err := <-ch; err != nil {
+			return nil, fmt.Errorf("failed to create pod %s: %v", names[i], err)
+		}
+	}
+	return names, nil
+}
+
+func (h *AEnvPlayHandler) playConfigMap(ctx context.Context, doc []byte, playID string) (string, error) {
+	var cm corev1.ConfigMap
+	if err := json.Unmarshal(doc, &cm); err != nil {
+		return "", fmt.Errorf("failed to decode configmap: %v", err)
+	}
+	cm.Namespace = h.namespace
+	cm.ResourceVersion = ""
+	cm.UID = ""
+	if cm.Labels == nil {
+		cm.Labels = make(map[string]string)
+	}
+	cm.Labels[playIDLabel] = playID
+
+	created, err := h.clientset.CoreV1().ConfigMaps(h.namespace).Create(ctx, &cm, metav1.CreateOptions{})
+	if err != nil {
+		return "", err
+	}
+	return created.Name, nil
+}
+
+func (h *AEnvPlayHandler) playSecret(ctx context.Context, doc []byte, playID string) (string, error) {
+	var secret corev1.Secret
+	if err := json.Unmarshal(doc, &secret); err != nil {
+		return "", fmt.Errorf("failed to decode secret: %v", err)
+	}
+	secret.Namespace = h.namespace
+	secret.ResourceVersion = ""
+	secret.UID = ""
+	if secret.Labels == nil {
+		secret.Labels = make(map[string]string)
+	}
+	secret.Labels[playIDLabel] = playID
+
+	created, err := h.clientset.CoreV1().Secrets(h.namespace).Create(ctx, &secret, metav1.CreateOptions{})
+	if err != nil {
+		return "", err
+	}
+	return created.Name, nil
+}
+
+// PlayDownRequest is the body of POST /envs/play/down.
+type PlayDownRequest struct {
+	PlayID string `json:"playId"`
+}
+
+// PlayDownResponse reports what a teardown removed.
+type PlayDownResponse struct {
+	PlayID     string   `json:"playId"`
+	Pods       []string `json:"pods,omitempty"`
+	ConfigMaps []string `json:"configMaps,omitempty"`
+	Secrets    []string `json:"secrets,omitempty"`
+}
+
+// playDown deletes every resource a prior POST /envs/play created, matching
+// them back up by playIDLabel the same way play set it.
+func (h *AEnvPlayHandler) playDown(w http.ResponseWriter, r *http.Request) {
+	var req PlayDownRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if req.PlayID == "" {
+		http.Error(w, "missing playId", http.StatusBadRequest)
+		return
+	}
+
+	selector := labels.SelectorFromSet(labels.Set{playIDLabel: req.PlayID})
+	resp := &PlayDownResponse{PlayID: req.PlayID}
+	ctx := r.Context()
+
+	pods, err := h.clientset.CoreV1().Pods(h.namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to list pods for play %s: %v", req.PlayID, err), http.StatusInternalServerError)
+		return
+	}
+	for _, pod := range pods.Items {
+		if err := h.clientset.CoreV1().Pods(h.namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{}); err != nil {
+			klog.Errorf("play down: failed to delete pod %s: %v", pod.Name, err)
+			continue
+		}
+		resp.Pods = append(resp.Pods, pod.Name)
+	}
+
+	configMaps, err := h.clientset.CoreV1().ConfigMaps(h.namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to list configmaps for play %s: %v", req.PlayID, err), http.StatusInternalServerError)
+		return
+	}
+	for _, cm := range configMaps.Items {
+		if err := h.clientset.CoreV1().ConfigMaps(h.namespace).Delete(ctx, cm.Name, metav1.DeleteOptions{}); err != nil {
+			klog.Errorf("play down: failed to delete configmap %s: %v", cm.Name, err)
+			continue
+		}
+		resp.ConfigMaps = append(resp.ConfigMaps, cm.Name)
+	}
+
+	secrets, err := h.clientset.CoreV1().Secrets(h.namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to list secrets for play %s: %v", req.PlayID, err), http.StatusInternalServerError)
+		return
+	}
+	for _, secret := range secrets.Items {
+		if err := h.clientset.CoreV1().Secrets(h.namespace).Delete(ctx, secret.Name, metav1.DeleteOptions{}); err != nil {
+			klog.Errorf("play down: failed to delete secret %s: %v", secret.Name, err)
+			continue
+		}
+		resp.Secrets = append(resp.Secrets, secret.Name)
+	}
+
+	h.mu.Lock()
+	envNames := h.plays[req.PlayID]
+	delete(h.plays, req.PlayID)
+	h.mu.Unlock()
+
+	for _, name := range envNames {
+		if h.prewarmReconciler != nil {
+			h.prewarmReconciler.RemoveDesired(name)
+		}
+		if h.envStore != nil {
+			if err := h.envStore.Delete(ctx, name); err != nil {
+				klog.Errorf("play down: failed to delete env %s: %v", name, err)
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		klog.Errorf("failed to encode response: %v", err)
+	}
+}
+
+// splitManifest splits a multi-document YAML manifest into individual
+// documents, each converted to JSON so the per-kind decoders above can use
+// the standard encoding/json unmarshalling Kubernetes' own API types expect.
+func splitManifest(manifest string) ([][]byte, error) {
+	decoder := k8syaml.NewYAMLOrJSONDecoder(bytes.NewReader([]byte(manifest)), 4096)
+
+	var docs [][]byte
+	for {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		if len(bytes.TrimSpace(raw)) == 0 {
+			continue
+		}
+		docs = append(docs, raw)
+	}
+	return docs, nil
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aenvhub_http_server
+
+import (
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// PodEventType names a pod state transition PodEventBus publishes.
+type PodEventType string
+
+const (
+	// PodEventAdded fires whenever a pod is first observed in the cache,
+	// regardless of phase, so subscribers that need to react to a pod's
+	// existence (e.g. TTLGCController scheduling its expiry) don't have to
+	// wait for it to reach Running.
+	PodEventAdded PodEventType = "Added"
+	// PodEventRunning fires when a pod's phase moves from Pending to Running.
+	PodEventRunning PodEventType = "Running"
+	// PodEventFailed fires when a pod's phase moves from Running to Failed.
+	PodEventFailed PodEventType = "Failed"
+	// PodEventDeleted fires when a pod is removed from the cache without a
+	// TTL label, i.e. a plain delete rather than a reaper sweep.
+	PodEventDeleted PodEventType = "Deleted"
+	// PodEventTTLExpired fires when a TTL-labeled pod is removed from the
+	// cache, e.g. by the PodReaper's sweep or POST /pods/prune.
+	PodEventTTLExpired PodEventType = "TTLExpired"
+)
+
+// PodEvent is one state transition observed by AEnvCacheManager's pod
+// informers.
+type PodEvent struct {
+	Type      PodEventType    `json:"type"`
+	Namespace string          `json:"namespace"`
+	PodName   string          `json:"podName"`
+	Phase     corev1.PodPhase `json:"phase,omitempty"`
+	Timestamp time.Time       `json:"timestamp"`
+}
+
+// podEventSubscriber is one Subscribe call's delivery channel.
+type podEventSubscriber struct {
+	ch chan PodEvent
+}
+
+// PodEventBus fans PodEvents out to subscribers, either for one pod name or,
+// via Subscribe(""), every pod in the cache. This is how controllers and the
+// api-service MCP gateway get push-style status updates instead of polling
+// getPod/listPod.
+type PodEventBus struct {
+	mu          sync.Mutex
+	subscribers map[string][]*podEventSubscriber
+}
+
+// NewPodEventBus creates an empty PodEventBus.
+func NewPodEventBus() *PodEventBus {
+	return &PodEventBus{subscribers: make(map[string][]*podEventSubscriber)}
+}
+
+// Subscribe registers interest in podName's events ("" subscribes to every
+// pod). The returned channel is buffered so a slow consumer doesn't block
+// publish; it is closed, and the subscription removed, by calling the
+// returned unsubscribe function.
+func (b *PodEventBus) Subscribe(podName string) (<-chan PodEvent, func()) {
+	sub := &podEventSubscriber{ch: make(chan PodEvent, 32)}
+
+	b.mu.Lock()
+	b.subscribers[podName] = append(b.subscribers[podName], sub)
+	b.mu.Unlock()
+
+	unsubscribe := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		subs := b.subscribers[podName]
+		for i, s := range subs {
+			if s == sub {
+				b.subscribers[podName] = append(subs[:i], subs[i+1:]...)
+				break
+			}
+		}
+		close(sub.ch)
+	}
+	return sub.ch, unsubscribe
+}
+
+// publish fans event out to podName's subscribers and the wildcard ("")
+// subscribers, dropping it for any subscriber whose buffer is full rather
+// than blocking the informer goroutine.
+func (b *PodEventBus) publish(event PodEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, key := range []string{event.PodName, ""} {
+		for _, sub := range b.subscribers[key] {
+			select {
+			case sub.ch <- event:
+			default:
+			}
+		}
+	}
+}
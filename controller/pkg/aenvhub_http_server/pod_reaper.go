@@ -0,0 +1,154 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aenvhub_http_server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"controller/pkg/metrics"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog"
+)
+
+const (
+	defaultReapInterval           = time.Minute
+	defaultReapGracePeriodSeconds = int64(30)
+)
+
+// PodReaper periodically deletes pods whose AENV_TTL label shows they have
+// expired, the same way Podman's container prune sweeps exited containers
+// on a timer. It also backs the on-demand POST /pods/prune endpoint, so a
+// manual prune and the background sweep always agree on what counts as
+// expired.
+type PodReaper struct {
+	clientset          kubernetes.Interface
+	cache              *AEnvCacheManager
+	namespace          string
+	interval           time.Duration
+	gracePeriodSeconds int64
+}
+
+// NewPodReaper creates a reaper that sweeps namespace every interval,
+// deleting expired pods with the given grace period.
+func NewPodReaper(clientset kubernetes.Interface, cache *AEnvCacheManager, namespace string, interval time.Duration, gracePeriodSeconds int64) *PodReaper {
+	return &PodReaper{
+		clientset:          clientset,
+		cache:              cache,
+		namespace:          namespace,
+		interval:           interval,
+		gracePeriodSeconds: gracePeriodSeconds,
+	}
+}
+
+// Run sweeps r.namespace every r.interval until stopCh is closed.
+func (r *PodReaper) Run(stopCh <-chan struct{}) {
+	klog.Infof("pod reaper starting, namespace %s, interval %v", r.namespace, r.interval)
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := r.Prune(context.Background(), PruneOptions{}); err != nil {
+				klog.Errorf("pod reaper sweep failed: %v", err)
+			}
+		case <-stopCh:
+			klog.Infof("pod reaper stopping")
+			return
+		}
+	}
+}
+
+// PruneOptions configures a single reaper pass.
+type PruneOptions struct {
+	// DryRun reports what would be deleted without calling Delete.
+	DryRun bool
+	// TTLOverride, when non-zero, replaces every pod's own AENV_TTL label
+	// for this pass, e.g. to preview the effect of a shorter TTL.
+	TTLOverride time.Duration
+}
+
+// PrunedPod records the outcome for one pod considered during a prune pass.
+type PrunedPod struct {
+	Name    string `json:"name"`
+	Deleted bool   `json:"deleted"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// PruneResult is the outcome of one reaper pass.
+type PruneResult struct {
+	Pods            []PrunedPod `json:"pods"`
+	ReclaimedCPU    string      `json:"reclaimedCpu"`
+	ReclaimedMemory string      `json:"reclaimedMemory"`
+}
+
+// Prune lists expired pods and, unless opts.DryRun, deletes them. It backs
+// both the background Run loop and the POST /pods/prune endpoint.
+func (r *PodReaper) Prune(ctx context.Context, opts PruneOptions) (*PruneResult, error) {
+	expired, err := r.cache.ListExpiredPods(ctx, r.namespace, opts.TTLOverride)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired pods: %v", err)
+	}
+
+	result := &PruneResult{Pods: make([]PrunedPod, 0, len(expired))}
+	reclaimedCPU := resource.NewQuantity(0, resource.DecimalSI)
+	reclaimedMemory := resource.NewQuantity(0, resource.BinarySI)
+
+	for _, pod := range expired {
+		if opts.DryRun {
+			result.Pods = append(result.Pods, PrunedPod{Name: pod.Name, Deleted: false, Reason: "dry-run"})
+			continue
+		}
+
+		grace := r.gracePeriodSeconds
+		propagation := metav1.DeletePropagationForeground
+		if err := r.clientset.CoreV1().Pods(r.namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{
+			GracePeriodSeconds: &grace,
+			PropagationPolicy:  &propagation,
+		}); err != nil {
+			metrics.RecordPodReapFailure(r.namespace)
+			klog.Errorf("pod reaper: failed to delete pod %s: %v", pod.Name, err)
+			result.Pods = append(result.Pods, PrunedPod{Name: pod.Name, Deleted: false, Reason: err.Error()})
+			continue
+		}
+
+		metrics.RecordPodReapDeletion(r.namespace)
+		klog.Infof("pod reaper: deleted expired pod %s", pod.Name)
+		result.Pods = append(result.Pods, PrunedPod{Name: pod.Name, Deleted: true})
+		addResourceRequests(reclaimedCPU, reclaimedMemory, pod)
+	}
+
+	result.ReclaimedCPU = reclaimedCPU.String()
+	result.ReclaimedMemory = reclaimedMemory.String()
+	return result, nil
+}
+
+func addResourceRequests(cpu, memory *resource.Quantity, pod *corev1.Pod) {
+	for _, c := range pod.Spec.Containers {
+		if q, ok := c.Resources.Requests[corev1.ResourceCPU]; ok {
+			cpu.Add(q)
+		}
+		if q, ok := c.Resources.Requests[corev1.ResourceMemory]; ok {
+			memory.Add(q)
+		}
+	}
+}
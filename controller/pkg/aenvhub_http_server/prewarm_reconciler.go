@@ -0,0 +1,222 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aenvhub_http_server
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"controller/pkg/metrics"
+	"controller/pkg/parallel"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog"
+)
+
+const defaultPrewarmReconcileInterval = 30 * time.Second
+
+// desiredPrewarm is one env's target pool size and the pod template new
+// replicas are created from.
+type desiredPrewarm struct {
+	size     int
+	template *corev1.Pod
+}
+
+// reconcileJob and createJob/deleteJob are zero-sized tag types, each giving
+// parallel.Enqueue its own coalescing namespace so a scale-up create for one
+// pod never coalesces with another pod's create, while two reconcile passes
+// for the same env do.
+type reconcileJob struct{}
+type createJob struct{}
+type deleteJob struct{}
+
+// PrewarmReconciler keeps each env's prewarmed pool at its desired size,
+// diffing desired vs. actual via the AEnvCacheManager env index and
+// submitting the resulting create/delete work to a bounded parallel.Pool so
+// hundreds of replicas across many envs don't fill sequentially.
+type PrewarmReconciler struct {
+	clientset kubernetes.Interface
+	cache     *AEnvCacheManager
+	namespace string
+	pool      *parallel.Pool
+	queue     *parallel.EnvQueue
+	interval  time.Duration
+
+	mu      sync.Mutex
+	desired map[string]desiredPrewarm
+}
+
+// NewPrewarmReconciler builds a reconciler sharing h's clientset/cache, the
+// same construction pattern NewAEnvExecHandler and NewAEnvPlayHandler use.
+// pool is the bounded worker pool (sized by --max-parallel-ops) that actually
+// runs the create/delete calls.
+func NewPrewarmReconciler(h *AEnvPodHandler, pool *parallel.Pool) *PrewarmReconciler {
+	return &PrewarmReconciler{
+		clientset: h.clientset,
+		cache:     h.cacheManager,
+		namespace: h.namespace,
+		pool:      pool,
+		queue:     parallel.NewEnvQueue(),
+		interval:  defaultPrewarmReconcileInterval,
+		desired:   make(map[string]desiredPrewarm),
+	}
+}
+
+// SetDesired registers (or updates) env's target pool size and the pod
+// template used to fill it, then immediately requests a reconcile.
+func (r *PrewarmReconciler) SetDesired(env string, size int, template *corev1.Pod) {
+	r.mu.Lock()
+	r.desired[env] = desiredPrewarm{size: size, template: template}
+	r.mu.Unlock()
+	r.queue.Add(env)
+}
+
+// RemoveDesired stops reconciling env, e.g. once its play/teardown deletes it.
+func (r *PrewarmReconciler) RemoveDesired(env string) {
+	r.mu.Lock()
+	delete(r.desired, env)
+	r.mu.Unlock()
+}
+
+// Run periodically re-queues every known env for reconciliation and
+// processes the queue, until stopCh is closed.
+func (r *PrewarmReconciler) Run(stopCh <-chan struct{}) {
+	klog.Infof("prewarm reconciler starting, interval %v", r.interval)
+	go r.processQueue(stopCh)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.mu.Lock()
+			envs := make([]string, 0, len(r.desired))
+			for env := range r.desired {
+				envs = append(envs, env)
+			}
+			r.mu.Unlock()
+			for _, env := range envs {
+				r.queue.Add(env)
+			}
+		case <-stopCh:
+			klog.Infof("prewarm reconciler stopping")
+			r.queue.ShutDown()
+			return
+		}
+	}
+}
+
+func (r *PrewarmReconciler) processQueue(stopCh <-chan struct{}) {
+	for {
+		env, shutdown := r.queue.Get()
+		if shutdown {
+			return
+		}
+		if err := r.Reconcile(env); err != nil {
+			klog.Errorf("prewarm reconcile failed for env %s: %v", env, err)
+			r.queue.Failed(env)
+		} else {
+			r.queue.Succeeded(env)
+		}
+		r.queue.Done(env)
+	}
+}
+
+// Reconcile diffs env's desired vs. actual pool size and fills/drains it.
+// Concurrent Reconcile calls for the same env are coalesced onto a single
+// in-flight pass by the underlying pool.
+func (r *PrewarmReconciler) Reconcile(env string) error {
+	resultCh := parallel.Enqueue[reconcileJob](r.pool, env, func() error {
+		return r.reconcileOnce(env)
+	})
+	return <-resultCh
+}
+
+func (r *PrewarmReconciler) reconcileOnce(env string) error {
+	start := time.Now()
+	defer metrics.RecordPrewarmReconcileLatency(env, start)
+
+	r.mu.Lock()
+	desired, ok := r.desired[env]
+	r.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	actual, err := r.cache.ListPodsByEnv(env)
+	if err != nil {
+		return fmt.Errorf("failed to list pods for env %s: %v", env, err)
+	}
+
+	delta := desired.size - len(actual)
+	switch {
+	case delta > 0:
+		return r.scaleUp(env, desired.template, delta)
+	case delta < 0:
+		return r.scaleDown(env, actual, -delta)
+	default:
+		return nil
+	}
+}
+
+func (r *PrewarmReconciler) scaleUp(env string, template *corev1.Pod, count int) error {
+	results := make([]<-chan error, 0, count)
+	for i := 0; i < count; i++ {
+		pod := template.DeepCopy()
+		pod.Name = fmt.Sprintf("%s-%s", env, RandString(6))
+		AddLabelToPod(pod, env, "")
+
+		key := fmt.Sprintf("%s/%s", env, pod.Name)
+		results = append(results, parallel.Enqueue[createJob](r.pool, key, func() error {
+			_, err := r.clientset.CoreV1().Pods(r.namespace).Create(context.Background(), pod, metav1.CreateOptions{})
+			return err
+		}))
+	}
+	return waitAll(results)
+}
+
+func (r *PrewarmReconciler) scaleDown(env string, actual []*corev1.Pod, count int) error {
+	if count > len(actual) {
+		count = len(actual)
+	}
+	results := make([]<-chan error, 0, count)
+	for i := 0; i < count; i++ {
+		pod := actual[i]
+		key := fmt.Sprintf("%s/%s", env, pod.Name)
+		results = append(results, parallel.Enqueue[deleteJob](r.pool, key, func() error {
+			return r.clientset.CoreV1().Pods(r.namespace).Delete(context.Background(), pod.Name, metav1.DeleteOptions{})
+		}))
+	}
+	return waitAll(results)
+}
+
+// waitAll drains every result channel, returning the first error seen (if
+// any) only after every channel has been read, so a failure on one replica
+// never leaves another's create/delete call unaccounted for.
+func waitAll(results []<-chan error) error {
+	var firstErr error
+	for _, ch := range results {
+		if err := <-ch; err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,253 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aenvhub_http_server
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"controller/pkg/model"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Structured codes ResourcePolicyError carries, so callers can branch on
+// Code instead of parsing Message text.
+const (
+	ResourcePolicyCodeInvalidQuantity = "INVALID_RESOURCE_QUANTITY"
+	ResourcePolicyCodeOutOfBounds     = "RESOURCE_OUT_OF_BOUNDS"
+	ResourcePolicyCodeTTLExceedsMax   = "TTL_EXCEEDS_MAX"
+	ResourcePolicyCodeInvalidTTL      = "INVALID_TTL"
+	ResourcePolicyCodeEnvVarDenied    = "ENV_VAR_DENIED"
+)
+
+// ResourcePolicyError is a structured ResourcePolicy violation, returned to
+// the caller as a 400 with Code/Message instead of a klog.Errorf that's
+// silently swallowed by applyConfig.
+type ResourcePolicyError struct {
+	Code    string
+	Message string
+}
+
+func (e *ResourcePolicyError) Error() string {
+	return e.Message
+}
+
+// ResourcePolicy validates an AEnvHubEnv's deploy config before its pod is
+// sent to Pods().Create, and may mutate aenv.DeployConfig in place to fill in
+// defaults for fields the caller omitted.
+type ResourcePolicy interface {
+	Validate(namespace string, aenv *model.AEnvHubEnv) error
+}
+
+// ResourceBounds is the allowed cpu/memory range for one namespace.
+type ResourceBounds struct {
+	MinCPU    resource.Quantity
+	MaxCPU    resource.Quantity
+	MinMemory resource.Quantity
+	MaxMemory resource.Quantity
+}
+
+// DefaultResourcePolicy is the ResourcePolicy createPod uses unless
+// overridden by SetResourcePolicy.
+type DefaultResourcePolicy struct {
+	// Bounds is keyed by namespace, with "" as the fallback used for any
+	// namespace without an explicit entry.
+	Bounds map[string]ResourceBounds
+	// MaxTTL caps how long a pod's AENV_TTL label is allowed to request.
+	MaxTTL time.Duration
+	// EnvDenylistPrefixes rejects any environmentVariables key starting with
+	// one of these prefixes (case-insensitive), e.g. "KUBERNETES_", "AWS_",
+	// guarding against a payload trying to shadow the pod's own service
+	// account credentials or cloud metadata.
+	EnvDenylistPrefixes []string
+	// DefaultLimitRange, when set, supplies the cpu/memory values injected
+	// into DeployConfig when a request omits them entirely.
+	DefaultLimitRange *corev1.LimitRange
+}
+
+// defaultMaxTTL is the ceiling NewDefaultResourcePolicy enforces absent an
+// explicit override.
+const defaultMaxTTL = 7 * 24 * time.Hour
+
+// NewDefaultResourcePolicy builds a DefaultResourcePolicy with the bounds
+// createPod has always implicitly assumed were sane, now made explicit and
+// enforced instead of left to whatever applyConfig happened to accept.
+func NewDefaultResourcePolicy() *DefaultResourcePolicy {
+	return &DefaultResourcePolicy{
+		Bounds: map[string]ResourceBounds{
+			"": {
+				MinCPU:    resource.MustParse("10m"),
+				MaxCPU:    resource.MustParse("8"),
+				MinMemory: resource.MustParse("16Mi"),
+				MaxMemory: resource.MustParse("16Gi"),
+			},
+		},
+		MaxTTL:              defaultMaxTTL,
+		EnvDenylistPrefixes: []string{"KUBERNETES_", "AWS_"},
+	}
+}
+
+// Validate implements ResourcePolicy.
+func (p *DefaultResourcePolicy) Validate(namespace string, aenv *model.AEnvHubEnv) error {
+	if err := p.validateResources(namespace, aenv); err != nil {
+		return err
+	}
+	if err := p.validateTTL(aenv); err != nil {
+		return err
+	}
+	return p.validateEnvironmentVariables(aenv)
+}
+
+func (p *DefaultResourcePolicy) boundsFor(namespace string) ResourceBounds {
+	if bounds, ok := p.Bounds[namespace]; ok {
+		return bounds
+	}
+	return p.Bounds[""]
+}
+
+// validateResources enforces cpu/memory bounds when the request opts into
+// autoscale sizing (applyConfig's own gate), and otherwise injects
+// DefaultLimitRange's values when cpu/memory are omitted entirely.
+func (p *DefaultResourcePolicy) validateResources(namespace string, aenv *model.AEnvHubEnv) error {
+	configs := aenv.DeployConfig
+	if configs == nil {
+		return nil
+	}
+
+	_, hasCPU := configs["cpu"]
+	_, hasMemory := configs["memory"]
+	if configs["resource"] != "autoscale" {
+		if !hasCPU && !hasMemory {
+			p.injectLimitRangeDefaults(configs)
+		}
+		return nil
+	}
+
+	bounds := p.boundsFor(namespace)
+	cpuQty, err := parseConfiguredQuantity(configs, "cpu")
+	if err != nil {
+		return err
+	}
+	if cpuQty != nil && (cpuQty.Cmp(bounds.MinCPU) < 0 || cpuQty.Cmp(bounds.MaxCPU) > 0) {
+		return &ResourcePolicyError{
+			Code:    ResourcePolicyCodeOutOfBounds,
+			Message: fmt.Sprintf("cpu %s is outside the allowed range [%s, %s] for namespace %q", cpuQty.String(), bounds.MinCPU.String(), bounds.MaxCPU.String(), namespace),
+		}
+	}
+
+	memQty, err := parseConfiguredQuantity(configs, "memory")
+	if err != nil {
+		return err
+	}
+	if memQty != nil && (memQty.Cmp(bounds.MinMemory) < 0 || memQty.Cmp(bounds.MaxMemory) > 0) {
+		return &ResourcePolicyError{
+			Code:    ResourcePolicyCodeOutOfBounds,
+			Message: fmt.Sprintf("memory %s is outside the allowed range [%s, %s] for namespace %q", memQty.String(), bounds.MinMemory.String(), bounds.MaxMemory.String(), namespace),
+		}
+	}
+	return nil
+}
+
+// parseConfiguredQuantity parses configs[key] as a resource.Quantity,
+// returning nil (not an error) when the key is absent.
+func parseConfiguredQuantity(configs map[string]interface{}, key string) (*resource.Quantity, error) {
+	raw, ok := configs[key]
+	if !ok {
+		return nil, nil
+	}
+	str, ok := raw.(string)
+	if !ok {
+		return nil, &ResourcePolicyError{Code: ResourcePolicyCodeInvalidQuantity, Message: fmt.Sprintf("%s must be a string quantity, got %T", key, raw)}
+	}
+	qty, err := resource.ParseQuantity(str)
+	if err != nil {
+		return nil, &ResourcePolicyError{Code: ResourcePolicyCodeInvalidQuantity, Message: fmt.Sprintf("invalid %s quantity %q: %v", key, str, err)}
+	}
+	return &qty, nil
+}
+
+// injectLimitRangeDefaults fills configs["cpu"]/["memory"] from
+// DefaultLimitRange's Container-scoped Default entry and flips on autoscale
+// sizing, so applyConfig actually applies what was just injected.
+func (p *DefaultResourcePolicy) injectLimitRangeDefaults(configs map[string]interface{}) {
+	if p.DefaultLimitRange == nil {
+		return
+	}
+	for _, item := range p.DefaultLimitRange.Spec.Limits {
+		if item.Type != corev1.LimitTypeContainer {
+			continue
+		}
+		if cpu, ok := item.Default[corev1.ResourceCPU]; ok {
+			configs["cpu"] = cpu.String()
+		}
+		if mem, ok := item.Default[corev1.ResourceMemory]; ok {
+			configs["memory"] = mem.String()
+		}
+		configs["resource"] = "autoscale"
+		return
+	}
+}
+
+// validateTTL rejects a ttl deployConfig entry that isn't a parseable
+// duration, or that exceeds MaxTTL, before createPod's own (unguarded) type
+// assertion on the same field.
+func (p *DefaultResourcePolicy) validateTTL(aenv *model.AEnvHubEnv) error {
+	raw, ok := aenv.DeployConfig["ttl"]
+	if !ok || raw == nil {
+		return nil
+	}
+	str, ok := raw.(string)
+	if !ok {
+		return &ResourcePolicyError{Code: ResourcePolicyCodeInvalidTTL, Message: fmt.Sprintf("ttl must be a string duration, got %T", raw)}
+	}
+	ttl, err := time.ParseDuration(str)
+	if err != nil {
+		return &ResourcePolicyError{Code: ResourcePolicyCodeInvalidTTL, Message: fmt.Sprintf("invalid ttl %q: %v", str, err)}
+	}
+	if p.MaxTTL > 0 && ttl > p.MaxTTL {
+		return &ResourcePolicyError{
+			Code:    ResourcePolicyCodeTTLExceedsMax,
+			Message: fmt.Sprintf("ttl %s exceeds the maximum allowed ttl %s", ttl, p.MaxTTL),
+		}
+	}
+	return nil
+}
+
+// validateEnvironmentVariables rejects any key in configs["environmentVariables"]
+// that starts with a denylisted prefix, guarding against a payload trying to
+// shadow the pod's own service account token or cloud credentials.
+func (p *DefaultResourcePolicy) validateEnvironmentVariables(aenv *model.AEnvHubEnv) error {
+	environmentVariables, ok := aenv.DeployConfig["environmentVariables"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	for key := range environmentVariables {
+		upper := strings.ToUpper(key)
+		for _, prefix := range p.EnvDenylistPrefixes {
+			if strings.HasPrefix(upper, prefix) {
+				return &ResourcePolicyError{
+					Code:    ResourcePolicyCodeEnvVarDenied,
+					Message: fmt.Sprintf("environment variable %q is not allowed (matches denylisted prefix %q)", key, prefix),
+				}
+			}
+		}
+	}
+	return nil
+}
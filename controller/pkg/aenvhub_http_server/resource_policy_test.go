@@ -0,0 +1,227 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aenvhub_http_server
+
+import (
+	"testing"
+	"time"
+
+	"controller/pkg/model"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func policyErrorCode(err error) string {
+	if perr, ok := err.(*ResourcePolicyError); ok {
+		return perr.Code
+	}
+	return ""
+}
+
+func TestDefaultResourcePolicy_ValidateResources_WithinBounds(t *testing.T) {
+	p := NewDefaultResourcePolicy()
+	aenv := &model.AEnvHubEnv{
+		DeployConfig: map[string]interface{}{
+			"resource": "autoscale",
+			"cpu":      "1",
+			"memory":   "1Gi",
+		},
+	}
+	if err := p.Validate("", aenv); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestDefaultResourcePolicy_ValidateResources_OutOfBounds(t *testing.T) {
+	p := NewDefaultResourcePolicy()
+	aenv := &model.AEnvHubEnv{
+		DeployConfig: map[string]interface{}{
+			"resource": "autoscale",
+			"cpu":      "16",
+			"memory":   "1Gi",
+		},
+	}
+	err := p.Validate("", aenv)
+	if err == nil {
+		t.Fatal("expected an error for cpu above the namespace bound")
+	}
+	if code := policyErrorCode(err); code != ResourcePolicyCodeOutOfBounds {
+		t.Fatalf("expected code %s, got %s", ResourcePolicyCodeOutOfBounds, code)
+	}
+}
+
+func TestDefaultResourcePolicy_ValidateResources_InvalidQuantity(t *testing.T) {
+	p := NewDefaultResourcePolicy()
+	aenv := &model.AEnvHubEnv{
+		DeployConfig: map[string]interface{}{
+			"resource": "autoscale",
+			"cpu":      "not-a-quantity",
+		},
+	}
+	err := p.Validate("", aenv)
+	if err == nil {
+		t.Fatal("expected an error for an unparseable cpu quantity")
+	}
+	if code := policyErrorCode(err); code != ResourcePolicyCodeInvalidQuantity {
+		t.Fatalf("expected code %s, got %s", ResourcePolicyCodeInvalidQuantity, code)
+	}
+}
+
+func TestDefaultResourcePolicy_ValidateResources_SkipsBoundsWithoutAutoscale(t *testing.T) {
+	p := NewDefaultResourcePolicy()
+	aenv := &model.AEnvHubEnv{
+		DeployConfig: map[string]interface{}{
+			"cpu":    "16",
+			"memory": "1Gi",
+		},
+	}
+	if err := p.Validate("", aenv); err != nil {
+		t.Fatalf("expected no error when resource sizing isn't autoscale, got %v", err)
+	}
+}
+
+func TestDefaultResourcePolicy_InjectLimitRangeDefaults(t *testing.T) {
+	p := NewDefaultResourcePolicy()
+	p.DefaultLimitRange = &corev1.LimitRange{
+		Spec: corev1.LimitRangeSpec{
+			Limits: []corev1.LimitRangeItem{
+				{
+					Type: corev1.LimitTypeContainer,
+					Default: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("500m"),
+						corev1.ResourceMemory: resource.MustParse("512Mi"),
+					},
+				},
+			},
+		},
+	}
+	configs := map[string]interface{}{}
+	p.injectLimitRangeDefaults(configs)
+
+	if configs["cpu"] != "500m" {
+		t.Errorf("expected injected cpu default 500m, got %v", configs["cpu"])
+	}
+	if configs["memory"] != "512Mi" {
+		t.Errorf("expected injected memory default 512Mi, got %v", configs["memory"])
+	}
+	if configs["resource"] != "autoscale" {
+		t.Errorf("expected resource to be flipped to autoscale, got %v", configs["resource"])
+	}
+}
+
+func TestDefaultResourcePolicy_InjectLimitRangeDefaults_NilRange(t *testing.T) {
+	p := NewDefaultResourcePolicy()
+	configs := map[string]interface{}{}
+	p.injectLimitRangeDefaults(configs)
+
+	if len(configs) != 0 {
+		t.Errorf("expected no mutation when DefaultLimitRange is nil, got %v", configs)
+	}
+}
+
+func TestDefaultResourcePolicy_ValidateTTL(t *testing.T) {
+	p := NewDefaultResourcePolicy()
+	p.MaxTTL = time.Hour
+
+	cases := []struct {
+		name    string
+		ttl     interface{}
+		wantErr string
+	}{
+		{name: "absent", ttl: nil, wantErr: ""},
+		{name: "within max", ttl: "30m", wantErr: ""},
+		{name: "exceeds max", ttl: "2h", wantErr: ResourcePolicyCodeTTLExceedsMax},
+		{name: "not a string", ttl: 3600, wantErr: ResourcePolicyCodeInvalidTTL},
+		{name: "unparseable", ttl: "not-a-duration", wantErr: ResourcePolicyCodeInvalidTTL},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			aenv := &model.AEnvHubEnv{DeployConfig: map[string]interface{}{}}
+			if tc.ttl != nil {
+				aenv.DeployConfig["ttl"] = tc.ttl
+			}
+			err := p.validateTTL(aenv)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected error code %s, got nil", tc.wantErr)
+			}
+			if code := policyErrorCode(err); code != tc.wantErr {
+				t.Fatalf("expected code %s, got %s", tc.wantErr, code)
+			}
+		})
+	}
+}
+
+func TestDefaultResourcePolicy_ValidateEnvironmentVariables(t *testing.T) {
+	p := NewDefaultResourcePolicy()
+
+	aenv := &model.AEnvHubEnv{
+		DeployConfig: map[string]interface{}{
+			"environmentVariables": map[string]interface{}{
+				"MY_VAR":          "value",
+				"kubernetes_port": "10.0.0.1",
+			},
+		},
+	}
+	err := p.validateEnvironmentVariables(aenv)
+	if err == nil {
+		t.Fatal("expected an error for a denylisted env var prefix (case-insensitive)")
+	}
+	if code := policyErrorCode(err); code != ResourcePolicyCodeEnvVarDenied {
+		t.Fatalf("expected code %s, got %s", ResourcePolicyCodeEnvVarDenied, code)
+	}
+}
+
+func TestDefaultResourcePolicy_ValidateEnvironmentVariables_Allowed(t *testing.T) {
+	p := NewDefaultResourcePolicy()
+
+	aenv := &model.AEnvHubEnv{
+		DeployConfig: map[string]interface{}{
+			"environmentVariables": map[string]interface{}{
+				"MY_VAR": "value",
+			},
+		},
+	}
+	if err := p.validateEnvironmentVariables(aenv); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestParseConfiguredQuantity_Absent(t *testing.T) {
+	qty, err := parseConfiguredQuantity(map[string]interface{}{}, "cpu")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if qty != nil {
+		t.Fatalf("expected nil quantity for an absent key, got %v", qty)
+	}
+}
+
+func TestBoundsFor_FallsBackToDefault(t *testing.T) {
+	p := NewDefaultResourcePolicy()
+	bounds := p.boundsFor("some-namespace-without-an-entry")
+	if bounds.MaxCPU.Cmp(p.Bounds[""].MaxCPU) != 0 {
+		t.Fatalf("expected fallback bounds to match the \"\" entry, got %v", bounds)
+	}
+}
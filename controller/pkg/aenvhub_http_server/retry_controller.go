@@ -0,0 +1,224 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aenvhub_http_server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog"
+)
+
+// PodOpType names the kind of work a PodOpTask carries out.
+type PodOpType string
+
+const (
+	PodOpCreate PodOpType = "create"
+	PodOpDelete PodOpType = "delete"
+)
+
+// PodOpStatus is a PodOpTask's current lifecycle state.
+type PodOpStatus string
+
+const (
+	PodOpQueued    PodOpStatus = "Queued"
+	PodOpRunning   PodOpStatus = "Running"
+	PodOpSucceeded PodOpStatus = "Succeeded"
+	PodOpFailed    PodOpStatus = "Failed"
+)
+
+// defaultMaxRetries bounds how many times a retryable failure is requeued
+// before PodOpTask gives up and reports Failed.
+const defaultMaxRetries = 5
+
+// PodOpTask is one queued create/delete call and its outcome, returned by
+// GET /tasks/{id} so async callers can poll a 202 Accepted response.
+type PodOpTask struct {
+	ID        string      `json:"id"`
+	Op        PodOpType   `json:"op"`
+	PodName   string      `json:"podName"`
+	Status    PodOpStatus `json:"status"`
+	Attempts  int         `json:"attempts"`
+	Error     string      `json:"error,omitempty"`
+	CreatedAt time.Time   `json:"createdAt"`
+	UpdatedAt time.Time   `json:"updatedAt"`
+
+	pod *corev1.Pod
+}
+
+// RetryController queues pod create/delete calls behind a rate-limited
+// workqueue, so a burst of requests degrades into steady, backed-off apiserver
+// traffic instead of every caller's request hitting the apiserver at once.
+// Errors are classified as fatal (no point retrying, e.g. 400/403, or a 409
+// Conflict on delete) or
+// retryable (429/500/503/timeout); retryable failures are requeued with
+// backoff up to defaultMaxRetries before being reported Failed.
+type RetryController struct {
+	clientset kubernetes.Interface
+	namespace string
+	queue     workqueue.RateLimitingInterface
+
+	mu    sync.Mutex
+	tasks map[string]*PodOpTask
+}
+
+// NewRetryController builds a controller that queues create/delete calls
+// against namespace using limiter (see parallel.NewTokenBucketRateLimiter)
+// to pace retries.
+func NewRetryController(clientset kubernetes.Interface, namespace string, limiter workqueue.RateLimiter) *RetryController {
+	return &RetryController{
+		clientset: clientset,
+		namespace: namespace,
+		queue:     workqueue.NewRateLimitingQueue(limiter),
+		tasks:     make(map[string]*PodOpTask),
+	}
+}
+
+// Enqueue records a new task and schedules it for processing, returning
+// immediately with the task's initial (Queued) state.
+func (c *RetryController) Enqueue(op PodOpType, podName string, pod *corev1.Pod) *PodOpTask {
+	now := time.Now()
+	task := &PodOpTask{
+		ID:        RandString(16),
+		Op:        op,
+		PodName:   podName,
+		Status:    PodOpQueued,
+		CreatedAt: now,
+		UpdatedAt: now,
+		pod:       pod,
+	}
+
+	c.mu.Lock()
+	c.tasks[task.ID] = task
+	c.mu.Unlock()
+
+	c.queue.Add(task.ID)
+	return task
+}
+
+// GetTask looks up a previously enqueued task by ID.
+func (c *RetryController) GetTask(id string) (*PodOpTask, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	task, ok := c.tasks[id]
+	return task, ok
+}
+
+// Run starts workers goroutines processing the queue until stopCh closes.
+func (c *RetryController) Run(workers int, stopCh <-chan struct{}) {
+	klog.Infof("retry controller starting, namespace %s, workers %d", c.namespace, workers)
+	for i := 0; i < workers; i++ {
+		go c.worker()
+	}
+	<-stopCh
+	klog.Infof("retry controller stopping")
+	c.queue.ShutDown()
+}
+
+func (c *RetryController) worker() {
+	for c.processNextItem() {
+	}
+}
+
+func (c *RetryController) processNextItem() bool {
+	item, shutdown := c.queue.Get()
+	if shutdown {
+		return false
+	}
+	id := item.(string)
+	defer c.queue.Done(id)
+
+	task, ok := c.GetTask(id)
+	if !ok {
+		klog.Errorf("retry controller: unknown task %s", id)
+		c.queue.Forget(id)
+		return true
+	}
+
+	c.setStatus(task, PodOpRunning, "")
+	task.Attempts++
+
+	err := c.execute(task)
+	switch {
+	case err == nil:
+		c.queue.Forget(id)
+		c.setStatus(task, PodOpSucceeded, "")
+	case isFatal(task.Op, err):
+		c.queue.Forget(id)
+		c.setStatus(task, PodOpFailed, err.Error())
+		klog.Errorf("retry controller: task %s (%s %s) failed fatally: %v", task.ID, task.Op, task.PodName, err)
+	case c.queue.NumRequeues(id) >= defaultMaxRetries:
+		c.queue.Forget(id)
+		c.setStatus(task, PodOpFailed, err.Error())
+		klog.Errorf("retry controller: task %s (%s %s) exhausted retries: %v", task.ID, task.Op, task.PodName, err)
+	default:
+		c.setStatus(task, PodOpQueued, err.Error())
+		klog.Infof("retry controller: task %s (%s %s) failed retryably, requeuing: %v", task.ID, task.Op, task.PodName, err)
+		c.queue.AddRateLimited(id)
+	}
+	return true
+}
+
+func (c *RetryController) execute(task *PodOpTask) error {
+	ctx := context.Background()
+	switch task.Op {
+	case PodOpCreate:
+		createdPod, err := c.clientset.CoreV1().Pods(c.namespace).Create(ctx, task.pod, metav1.CreateOptions{})
+		if err != nil {
+			return err
+		}
+		task.pod = createdPod
+		return nil
+	case PodOpDelete:
+		err := c.clientset.CoreV1().Pods(c.namespace).Delete(ctx, task.PodName, metav1.DeleteOptions{})
+		if errors.IsNotFound(err) {
+			// Already gone, which is the caller's desired end state.
+			return nil
+		}
+		return err
+	default:
+		return nil
+	}
+}
+
+func (c *RetryController) setStatus(task *PodOpTask, status PodOpStatus, errMsg string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	task.Status = status
+	task.Error = errMsg
+	task.UpdatedAt = time.Now()
+}
+
+// isFatal reports whether err is a permanent failure not worth retrying.
+// For deletes, NotFound is handled as success before isFatal is consulted, so
+// it never needs to be classified here.
+func isFatal(op PodOpType, err error) bool {
+	switch {
+	case errors.IsBadRequest(err), errors.IsForbidden(err), errors.IsInvalid(err):
+		return true
+	case op == PodOpDelete && errors.IsConflict(err):
+		return true
+	default:
+		return false
+	}
+}
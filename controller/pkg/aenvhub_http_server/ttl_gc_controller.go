@@ -0,0 +1,308 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aenvhub_http_server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"controller/pkg/constants"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog"
+)
+
+// TTLFinalizer blocks a TTL-labeled pod's deletion until TTLGCController has
+// run its cleanup hook and cleared it, guaranteeing the hook runs exactly
+// once even if the pod is deleted by some other path (e.g. kubectl delete).
+const TTLFinalizer = "aenv.io/ttl-protection"
+
+// defaultExpiringSoonWindow is how far ahead GET /pods?filter=expiring-soon
+// looks by default.
+const defaultExpiringSoonWindow = 5 * time.Minute
+
+// TTLCleanupHook runs arbitrary downstream cleanup (e.g. decrementing a
+// Redis-backed instance counter, notifying a backend) before a TTL-expired
+// pod's finalizer is cleared and its deletion allowed to complete. A failing
+// hook is logged but does not block the finalizer from being cleared, since
+// retrying a stuck finalizer indefinitely would leave the pod around forever.
+type TTLCleanupHook interface {
+	BeforeDelete(ctx context.Context, pod *corev1.Pod) error
+}
+
+// TTLGCController schedules each TTL-labeled pod's expiry into a delay queue
+// as soon as it's observed in the cache, instead of PodReaper's full-namespace
+// scan every interval. On fire it re-validates the pod is still expired, asks
+// the clientset to delete it, runs hook, then clears TTLFinalizer so the
+// apiserver can complete the delete. PodReaper keeps running alongside this
+// as a slow backstop sweep, since the in-memory schedule here is lost across
+// a controller restart until the cache resyncs.
+type TTLGCController struct {
+	clientset kubernetes.Interface
+	cache     *AEnvCacheManager
+	namespace string
+	hook      TTLCleanupHook
+
+	queue workqueue.DelayingInterface
+
+	mu        sync.Mutex
+	scheduled map[string]time.Time
+}
+
+// NewTTLGCController builds a controller scoped to namespace. hook may be
+// nil, in which case it defaults to noopTTLCleanupHook so the absence of
+// real downstream cleanup (Redis instance counter decrement, backend
+// notification) shows up as a warning log on every expiry instead of
+// silently doing nothing. Call SetTTLCleanupHook (on the owning
+// AEnvPodHandler) with a real implementation once one is wired up.
+func NewTTLGCController(clientset kubernetes.Interface, cache *AEnvCacheManager, namespace string, hook TTLCleanupHook) *TTLGCController {
+	if hook == nil {
+		hook = noopTTLCleanupHook{}
+	}
+	return &TTLGCController{
+		clientset: clientset,
+		cache:     cache,
+		namespace: namespace,
+		hook:      hook,
+		queue:     workqueue.NewDelayingQueue(),
+		scheduled: make(map[string]time.Time),
+	}
+}
+
+// noopTTLCleanupHook is the TTLCleanupHook installed when no real one has
+// been configured. It performs no downstream cleanup - nothing in this
+// binary is wired up to decrement a Redis instance counter or notify a
+// backend yet - but logs each call so that gap stays visible in the TTL GC
+// controller's own logs rather than depending on a comment elsewhere in the
+// codebase not being lost.
+type noopTTLCleanupHook struct{}
+
+func (noopTTLCleanupHook) BeforeDelete(ctx context.Context, pod *corev1.Pod) error {
+	klog.Warningf("ttl gc: no downstream cleanup hook configured, skipping for pod %s/%s", pod.Namespace, pod.Name)
+	return nil
+}
+
+// Run schedules every already-cached TTL-labeled pod (so expiries aren't
+// missed across a restart), then reacts to PodEventAdded for pods created
+// afterward, until stopCh closes.
+func (c *TTLGCController) Run(stopCh <-chan struct{}) {
+	klog.Infof("ttl gc controller starting, namespace %s", c.namespace)
+
+	events, unsubscribe := c.cache.Events().Subscribe("")
+	defer unsubscribe()
+
+	if pods, err := c.cache.ListPodsByNamespace(c.namespace); err == nil {
+		for _, pod := range pods {
+			c.schedule(pod)
+		}
+	}
+
+	go c.processQueue()
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if event.Type != PodEventAdded || event.Namespace != c.namespace {
+				continue
+			}
+			if pod, err := c.cache.GetPod(event.Namespace, event.PodName); err == nil {
+				c.schedule(pod)
+			}
+		case <-stopCh:
+			klog.Infof("ttl gc controller stopping")
+			c.queue.ShutDown()
+			return
+		}
+	}
+}
+
+// schedule computes pod's TTL expiry and, if it carries one, adds it to the
+// delay queue (replacing any previous schedule for the same pod).
+func (c *TTLGCController) schedule(pod *corev1.Pod) {
+	expiry, ok := ttlExpiry(pod)
+	if !ok {
+		return
+	}
+
+	key := podGCKey(pod.Namespace, pod.Name)
+	c.mu.Lock()
+	c.scheduled[key] = expiry
+	c.mu.Unlock()
+
+	delay := time.Until(expiry)
+	if delay < 0 {
+		delay = 0
+	}
+	c.queue.AddAfter(key, delay)
+}
+
+// ExpiringSoon returns the "namespace/name" keys of every scheduled pod whose
+// expiry falls within the next `within` duration, backing
+// GET /pods?filter=expiring-soon.
+func (c *TTLGCController) ExpiringSoon(within time.Duration) []string {
+	if within <= 0 {
+		within = defaultExpiringSoonWindow
+	}
+	cutoff := time.Now().Add(within)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	keys := make([]string, 0, len(c.scheduled))
+	for key, expiry := range c.scheduled {
+		if !expiry.After(cutoff) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func (c *TTLGCController) processQueue() {
+	for {
+		item, shutdown := c.queue.Get()
+		if shutdown {
+			return
+		}
+		key := item.(string)
+		c.queue.Done(key)
+		if err := c.fire(key); err != nil {
+			klog.Errorf("ttl gc: cleanup failed for %s: %v", key, err)
+		}
+	}
+}
+
+// fire re-validates key's pod is still present and expired before deleting
+// it, since labels or TTL may have changed since it was scheduled.
+func (c *TTLGCController) fire(key string) error {
+	namespace, name := splitGCKey(key)
+	pod, err := c.cache.GetPod(namespace, name)
+	if err != nil {
+		// Already gone, e.g. deleted directly via DELETE /pods/{name}.
+		c.forget(key)
+		return nil
+	}
+
+	expiry, ok := ttlExpiry(pod)
+	if !ok {
+		c.forget(key)
+		return nil
+	}
+	if time.Now().Before(expiry) {
+		// TTL label was bumped since this fire was scheduled; reschedule.
+		c.schedule(pod)
+		return nil
+	}
+
+	defer c.forget(key)
+	return c.gracefulDelete(pod)
+}
+
+// gracefulDelete deletes pod, runs hook (if set), then clears TTLFinalizer so
+// the apiserver can complete a delete that the finalizer was blocking.
+func (c *TTLGCController) gracefulDelete(pod *corev1.Pod) error {
+	ctx := context.Background()
+
+	if err := c.clientset.CoreV1().Pods(pod.Namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete pod %s/%s: %v", pod.Namespace, pod.Name, err)
+	}
+
+	if c.hook != nil {
+		if err := c.hook.BeforeDelete(ctx, pod); err != nil {
+			klog.Errorf("ttl gc: cleanup hook failed for pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		}
+	}
+
+	return c.clearFinalizer(ctx, pod.Namespace, pod.Name)
+}
+
+// clearFinalizer removes TTLFinalizer from the pod's current finalizer list,
+// re-fetching it first since gracefulDelete's Delete call may have changed
+// its resourceVersion.
+func (c *TTLGCController) clearFinalizer(ctx context.Context, namespace, name string) error {
+	current, err := c.clientset.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to refetch pod %s/%s before clearing ttl finalizer: %v", namespace, name, err)
+	}
+
+	finalizers := current.Finalizers[:0]
+	changed := false
+	for _, f := range current.Finalizers {
+		if f == TTLFinalizer {
+			changed = true
+			continue
+		}
+		finalizers = append(finalizers, f)
+	}
+	if !changed {
+		return nil
+	}
+
+	current.Finalizers = finalizers
+	if _, err := c.clientset.CoreV1().Pods(namespace).Update(ctx, current, metav1.UpdateOptions{}); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to clear ttl finalizer on pod %s/%s: %v", namespace, name, err)
+	}
+	klog.Infof("ttl gc: cleared finalizer, pod %s/%s deletion can complete", namespace, name)
+	return nil
+}
+
+func (c *TTLGCController) forget(key string) {
+	c.mu.Lock()
+	delete(c.scheduled, key)
+	c.mu.Unlock()
+}
+
+// ttlExpiry reports when pod's AENV_TTL label says it expires, and whether
+// it carries a parseable TTL at all. Pods labeled prunePolicyLabel=false are
+// excluded, matching PodReaper.Prune's exclusion rule.
+func ttlExpiry(pod *corev1.Pod) (time.Time, bool) {
+	if pod.Labels[prunePolicyLabel] == "false" {
+		return time.Time{}, false
+	}
+	ttlValue := pod.Labels[constants.AENV_TTL]
+	if ttlValue == "" {
+		return time.Time{}, false
+	}
+	ttl, err := time.ParseDuration(ttlValue)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return pod.CreationTimestamp.Add(ttl), true
+}
+
+func podGCKey(namespace, name string) string {
+	return namespace + "/" + name
+}
+
+func splitGCKey(key string) (namespace, name string) {
+	parts := strings.SplitN(key, "/", 2)
+	if len(parts) != 2 {
+		return "", key
+	}
+	return parts[0], parts[1]
+}
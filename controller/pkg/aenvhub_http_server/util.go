@@ -29,8 +29,13 @@ import (
 )
 
 const (
-	letters                 = "abcdefghijklmnopqrstuvwxyz0123456789" // ABCDEFGHIJKLMNOPQRSTUVWXYZ
-	envInstanceName         = "env-pod-pool-name"
+	letters         = "abcdefghijklmnopqrstuvwxyz0123456789" // ABCDEFGHIJKLMNOPQRSTUVWXYZ
+	envInstanceName = "env-pod-pool-name"
+	clusterLabel    = "aenv-cluster"
+	// prunePolicyLabel lets a pod opt out of the PodReaper's TTL sweep (e.g.
+	// "aenv-prune=false") the same way `kubectl apply --prune` respects a
+	// prune exclusion label.
+	prunePolicyLabel        = "aenv-prune"
 	AMD64                   = "amd64"
 	WIN64                   = "win64"
 	SingleContainerTemplate = "singleContainer"
@@ -45,13 +50,6 @@ func RandString(n int) string {
 	return string(b)
 }
 
-func MergePodWithFields(pod *corev1.Pod, Labels map[string]string,
-	Environs map[string]string,
-	Memory int,
-	EphemeralStorage int64) {
-
-}
-
 // AddLabelToPod adds label key=value to Pod
 func AddLabelToPod(pod *corev1.Pod, poolName string, description string) {
 	if pod == nil {
@@ -64,100 +62,63 @@ func AddLabelToPod(pod *corev1.Pod, poolName string, description string) {
 	// pod.Labels[envInstanceDescription] = description
 }
 
+// MergePod merges labels/environs/memory/ephemeralStorage/image onto pod by
+// building a strategic-merge patch and applying it with the corev1.Pod
+// schema, so env vars merge on name and containers merge on name instead of
+// pod being mutated field-by-field. memory/ephemeralStorage outside the
+// bounds EnvCreateRequest's binding tags describe are left untouched, same
+// as before.
 func MergePod(pod *corev1.Pod, labels map[string]string, environs map[string]string, memory int, ephemeralStorage int64, image string) {
-	// Pre-calculate byte boundaries for resource validation
-	const (
-		minMemoryBytes           = 256 * 1024 * 1024       // 256MiB
-		maxMemoryBytes           = 8 * 1024 * 1024 * 1024  // 8GiB
-		minEphemeralStorageBytes = 1 * 1024 * 1024 * 1024  // 1GiB
-		maxEphemeralStorageBytes = 50 * 1024 * 1024 * 1024 // 50GiB
-	)
-
-	// Merge labels
-	if labels != nil {
-		if pod.Labels == nil {
-			pod.Labels = make(map[string]string)
-		}
-		for k, v := range labels {
-			pod.Labels[k] = v
-		}
-	}
-
-	// Merge environment variables
-	if environs != nil {
-		mergeEnvVars := func(containers []corev1.Container) {
-			for i := range containers {
-				container := &containers[i]
-				for k, v := range environs {
-					found := false
-					for j := range container.Env {
-						if container.Env[j].Name == k {
-							container.Env[j].Value = v
-							found = true
-							break
-						}
-					}
-					if !found {
-						container.Env = append(container.Env, corev1.EnvVar{
-							Name:  k,
-							Value: v,
-						})
-					}
-				}
-			}
-		}
-		mergeEnvVars(pod.Spec.InitContainers)
-		mergeEnvVars(pod.Spec.Containers)
-	}
-
-	// Helper to update container resources
-	updateResources := func(container *corev1.Container) {
-		// Validate and set memory resources
-		memoryBytes := int64(memory) * 1024 * 1024
-		if memory >= 256 && memory <= 8192 { // 256MiB-8192MiB (8GiB)
-			memQty := resource.NewQuantity(memoryBytes, resource.BinarySI)
-			if container.Resources.Requests == nil {
-				container.Resources.Requests = make(corev1.ResourceList)
-			}
-			container.Resources.Requests[corev1.ResourceMemory] = *memQty
-
-			if container.Resources.Limits == nil {
-				container.Resources.Limits = make(corev1.ResourceList)
-			}
-			container.Resources.Limits[corev1.ResourceMemory] = *memQty
-
-			// klog.Infof("set mem req to xxx, %v", container.Resources.Requests[corev1.ResourceMemory])
-			// klog.Infof("set mem limit to xxx, %v", container.Resources.Limits[corev1.ResourceMemory])
-		}
-
-		// Validate and set ephemeral storage resources
-		if ephemeralStorage >= minEphemeralStorageBytes && ephemeralStorage <= maxEphemeralStorageBytes {
-			storageQty := resource.NewQuantity(ephemeralStorage, resource.BinarySI)
-			if container.Resources.Requests == nil {
-				container.Resources.Requests = make(corev1.ResourceList)
-			}
-			container.Resources.Requests[corev1.ResourceEphemeralStorage] = *storageQty
-
-			if container.Resources.Limits == nil {
-				container.Resources.Limits = make(corev1.ResourceList)
-			}
-			container.Resources.Limits[corev1.ResourceEphemeralStorage] = *storageQty
+	var envVars []corev1.EnvVar
+	for k, v := range environs {
+		envVars = append(envVars, corev1.EnvVar{Name: k, Value: v})
+	}
+	resources := legacyResourceOverrides(memory, ephemeralStorage)
+
+	patchContainers := func(containers []corev1.Container) []corev1.Container {
+		var patched []corev1.Container
+		for _, c := range containers {
+			patched = append(patched, corev1.Container{
+				Name:      c.Name,
+				Image:     image,
+				Env:       envVars,
+				Resources: resources,
+			})
 		}
+		return patched
 	}
 
-	// Update resources for all containers
-	for i := range pod.Spec.InitContainers {
-		updateResources(&pod.Spec.InitContainers[i])
+	patch := &corev1.Pod{}
+	patch.Labels = labels
+	patch.Spec.InitContainers = patchContainers(pod.Spec.InitContainers)
+	patch.Spec.Containers = patchContainers(pod.Spec.Containers)
 
-		// Image
-		pod.Spec.InitContainers[i].Image = image
+	merged, err := strategicMergePod(pod, patch)
+	if err != nil {
+		klog.Errorf("failed to merge pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		return
 	}
-	for i := range pod.Spec.Containers {
-		updateResources(&pod.Spec.Containers[i])
+	*pod = *merged
+}
 
-		// Image
-		pod.Spec.Containers[i].Image = image
-	}
+// legacyResourceOverrides builds the Resources patch MergePod applies,
+// silently leaving memory/ephemeralStorage outside EnvCreateRequest's
+// binding-tag bounds unset rather than rejecting them; POST /envs/play's
+// podOverlay path uses ApplyOverlay's validateResourceBounds instead for
+// callers that want hard rejection.
+func legacyResourceOverrides(memory int, ephemeralStorage int64) corev1.ResourceRequirements {
+	res := corev1.ResourceRequirements{Requests: corev1.ResourceList{}, Limits: corev1.ResourceList{}}
+	if memory >= minMemoryMiB && memory <= maxMemoryMiB {
+		memQty := resource.NewQuantity(int64(memory)*1024*1024, resource.BinarySI)
+		res.Requests[corev1.ResourceMemory] = *memQty
+		res.Limits[corev1.ResourceMemory] = *memQty
+	}
+	if ephemeralStorage >= minEphemeralStorageBytes && ephemeralStorage <= maxEphemeralStorageBytes {
+		storageQty := resource.NewQuantity(ephemeralStorage, resource.BinarySI)
+		res.Requests[corev1.ResourceEphemeralStorage] = *storageQty
+		res.Limits[corev1.ResourceEphemeralStorage] = *storageQty
+	}
+	return res
 }
 
 // Machine type: win64, amd64, darwin64
@@ -0,0 +1,149 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Artifact mirrors model.Artifact so the CRD can be decoded without a
+// dependency from the apis package back onto controller/pkg/model.
+type Artifact struct {
+	ID      string `json:"id,omitempty"`
+	Type    string `json:"type,omitempty"`
+	Content string `json:"content,omitempty"`
+}
+
+// EnvContent mirrors model.EnvContent.
+type EnvContent struct {
+	ZipFile string `json:"zipFile,omitempty"`
+	OssURL  string `json:"ossUrl,omitempty"`
+	Image   string `json:"image,omitempty"`
+	// RawManifest is the original Kubernetes YAML this Env was translated
+	// from (see model.EnvContent.RawManifest).
+	RawManifest string `json:"rawManifest,omitempty"`
+}
+
+// Resource mirrors model.ResourceSpec. Manifest carries the raw unstructured
+// object so the CRD schema doesn't need to know every kind an environment
+// might roll out.
+type Resource struct {
+	// Manifest is the raw unstructured Kubernetes object (apiVersion/kind/metadata/...).
+	Manifest runtime.RawExtension `json:"manifest"`
+	// DependsOn lists "kind/name" references that must be installed and ready first.
+	DependsOn []string `json:"dependsOn,omitempty"`
+	// WaitFor is one of Ready, Available, JobComplete; empty skips the readiness gate.
+	WaitFor string `json:"waitFor,omitempty"`
+}
+
+// EnvSpec is the desired state of an Env.
+type EnvSpec struct {
+	// Description is a human readable description of the environment.
+	Description string `json:"description,omitempty"`
+	// Version of the environment image/artifacts.
+	Version string `json:"version,omitempty"`
+	// Tags used for environment discovery.
+	Tags []string `json:"tags,omitempty"`
+	// CodeURL points at the source used to build the environment.
+	CodeURL string `json:"codeUrl,omitempty"`
+	// Artifacts lists the buildable artifacts (image, whl, ...) for this environment.
+	Artifacts []Artifact `json:"artifacts,omitempty"`
+	// BuildConfig is forwarded verbatim to the build pipeline.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	BuildConfig map[string]string `json:"buildConfig,omitempty"`
+	// TestConfig is forwarded verbatim to the test pipeline.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	TestConfig map[string]string `json:"testConfig,omitempty"`
+	// DeployConfig drives pod template rendering, same shape the HTTP API accepts today.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	DeployConfig map[string]string `json:"deployConfig,omitempty"`
+
+	// The fields below carry the pool-level settings that used to live only
+	// in storage.EnvStorage's model.Env, so that CRDEnvStorage can round-trip
+	// them without a second custom resource.
+
+	// Content is the image/zip package used to start the environment.
+	Content EnvContent `json:"content,omitempty"`
+	// Stateful marks whether instances of this environment keep local state.
+	Stateful bool `json:"stateful,omitempty"`
+	// Labels are injected onto every pod created for this environment.
+	Labels map[string]string `json:"podLabels,omitempty"`
+	// Envs are environment variables injected into every container.
+	Envs map[string]string `json:"podEnvs,omitempty"`
+	// Memory is the allocated memory size in MiB.
+	Memory int `json:"memory,omitempty"`
+	// EphemeralStorage is the allocated disk size in bytes.
+	EphemeralStorage int64 `json:"ephemeralStorage,omitempty"`
+	// ExpiredTime is the pod idle recycle time, in seconds.
+	ExpiredTime int `json:"expiredTime,omitempty"`
+	// PrewarmSize is the number of prewarmed instances to keep ready.
+	PrewarmSize int `json:"prewarmSize,omitempty"`
+	// ClusterName is the member cluster this Env is scheduled onto.
+	ClusterName string `json:"clusterName,omitempty"`
+	// NamespaceName is the namespace EnvInstances are created in.
+	NamespaceName string `json:"namespaceName,omitempty"`
+
+	// Resources lists additional manifests (ConfigMaps, Services, Ingresses, ...)
+	// that must be rolled out, in dependency order, alongside the backing pod.
+	// When set, the reconciler installs these via controller/pkg/installer
+	// instead of creating a single pod directly.
+	Resources []Resource `json:"resources,omitempty"`
+}
+
+// EnvStatus is the observed state of an Env.
+type EnvStatus struct {
+	// Phase is the current lifecycle phase, one of the model.EnvStatus names
+	// (Init, Pending, Creating, Created, Testing, Verified, Ready, Released, Failed).
+	Phase string `json:"phase,omitempty"`
+	// PodName is the name of the backing pod once created.
+	PodName string `json:"podName,omitempty"`
+	// PodIP is the last observed pod IP.
+	PodIP string `json:"podIP,omitempty"`
+	// Reason carries a short machine-readable explanation when Phase is Failed.
+	Reason string `json:"reason,omitempty"`
+	// ObservedGeneration is the spec generation last reconciled.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Pod",type=string,JSONPath=`.status.podName`
+
+// Env is the Schema for the envs API, replacing the ad-hoc AEnvHubEnv struct
+// that used to be passed only through the /pods HTTP handler.
+type Env struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   EnvSpec   `json:"spec,omitempty"`
+	Status EnvStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// EnvList contains a list of Env.
+type EnvList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Env `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Env{}, &EnvList{})
+}
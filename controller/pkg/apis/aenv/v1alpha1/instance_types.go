@@ -0,0 +1,86 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AEnvInstanceSpec is the desired state of an AEnvInstance, carrying the
+// fields POST /pods used to marshal straight into model.AEnvHubEnv before
+// creating a Pod directly.
+type AEnvInstanceSpec struct {
+	// EnvName is the Env this instance was created from, if any.
+	EnvName string `json:"envName,omitempty"`
+	// Artifacts lists the buildable artifacts (image, whl, ...) to render into the pod.
+	Artifacts []Artifact `json:"artifacts,omitempty"`
+	// DeployConfig drives pod template rendering, same shape the HTTP API accepts today.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	DeployConfig map[string]string `json:"deployConfig,omitempty"`
+	// TTL is the pod idle recycle duration (e.g. "10m"); empty means no TTL enforcement.
+	TTL string `json:"ttl,omitempty"`
+	// ClusterName is the member cluster this instance was scheduled onto.
+	ClusterName string `json:"clusterName,omitempty"`
+}
+
+// AEnvInstanceStatus is the observed state of an AEnvInstance.
+type AEnvInstanceStatus struct {
+	// Phase is the current lifecycle phase, one of the model.EnvStatus names
+	// (Init, Pending, Creating, Created, Testing, Verified, Ready, Released, Failed).
+	Phase string `json:"phase,omitempty"`
+	// PodName is the name of the backing pod once created.
+	PodName string `json:"podName,omitempty"`
+	// PodIP is the last observed pod IP.
+	PodIP string `json:"podIP,omitempty"`
+	// NodeName is the node the backing pod was scheduled onto.
+	NodeName string `json:"nodeName,omitempty"`
+	// Reason carries a short machine-readable explanation when Phase is Failed.
+	Reason string `json:"reason,omitempty"`
+	// ObservedGeneration is the spec generation last reconciled.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Pod",type=string,JSONPath=`.status.podName`
+
+// AEnvInstance is the Schema for the aenvinstances API. It replaces the
+// imperative Pod-create path that used to live entirely inside
+// AEnvPodHandler.createPod: the HTTP layer now only creates/updates this CR,
+// and controller.InstanceReconciler owns creating, re-rendering and
+// TTL-expiring the backing Pod.
+type AEnvInstance struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   AEnvInstanceSpec   `json:"spec,omitempty"`
+	Status AEnvInstanceStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// AEnvInstanceList contains a list of AEnvInstance.
+type AEnvInstanceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AEnvInstance `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&AEnvInstance{}, &AEnvInstanceList{})
+}
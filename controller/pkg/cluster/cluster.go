@@ -0,0 +1,216 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cluster implements a federation-style registry of member
+// Kubernetes clusters an AEnvHubEnv can be scheduled onto.
+package cluster
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog"
+)
+
+// ClusterStatus is the last observed health of a member cluster.
+type ClusterStatus string
+
+const (
+	ClusterStatusUnknown ClusterStatus = "Unknown"
+	ClusterStatusReady   ClusterStatus = "Ready"
+	ClusterStatusOffline ClusterStatus = "Offline"
+)
+
+// Cluster describes one member cluster that AEnvHubEnv pods can be placed on.
+type Cluster struct {
+	// Name uniquely identifies the member cluster (used as EnvInstance.ClusterName).
+	Name string
+	// Labels are matched against AEnvHubEnv.Scheduling.ClusterSelector.
+	Labels map[string]string
+	// Weight biases placement among clusters that otherwise match equally.
+	Weight int32
+
+	// Kubeconfig is a path to a kubeconfig file for this cluster. Mutually
+	// exclusive with BearerToken/Server/CAFile (the in-cluster style below).
+	Kubeconfig string
+	// Server, BearerToken and CAFile describe the cluster directly, for
+	// clusters registered without a kubeconfig file on disk.
+	Server      string
+	BearerToken string
+	CAFile      string
+
+	clientset kubernetes.Interface
+	status    ClusterStatus
+	mu        sync.RWMutex
+}
+
+// BuildConfig resolves the *rest.Config for this cluster, following the same
+// kubeconfig-or-token pattern used by AEnvPodHandler for the local cluster.
+func (c *Cluster) BuildConfig() (*rest.Config, error) {
+	if c.Kubeconfig != "" {
+		cfg, err := clientcmd.BuildConfigFromFlags("", c.Kubeconfig)
+		if err != nil {
+			return nil, fmt.Errorf("cluster %s: failed to load kubeconfig %s: %v", c.Name, c.Kubeconfig, err)
+		}
+		return cfg, nil
+	}
+	if c.Server == "" {
+		return nil, fmt.Errorf("cluster %s: neither kubeconfig nor server/token provided", c.Name)
+	}
+	return &rest.Config{
+		Host:        c.Server,
+		BearerToken: c.BearerToken,
+		TLSClientConfig: rest.TLSClientConfig{
+			CAFile: c.CAFile,
+		},
+	}, nil
+}
+
+// Clientset lazily builds and caches the kubernetes.Interface for this cluster.
+func (c *Cluster) Clientset() (kubernetes.Interface, error) {
+	c.mu.RLock()
+	if c.clientset != nil {
+		defer c.mu.RUnlock()
+		return c.clientset, nil
+	}
+	c.mu.RUnlock()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.clientset != nil {
+		return c.clientset, nil
+	}
+	cfg, err := c.BuildConfig()
+	if err != nil {
+		return nil, err
+	}
+	cs, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("cluster %s: failed to build clientset: %v", c.Name, err)
+	}
+	c.clientset = cs
+	return cs, nil
+}
+
+// Status returns the last health check result for this cluster.
+func (c *Cluster) Status() ClusterStatus {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.status == "" {
+		return ClusterStatusUnknown
+	}
+	return c.status
+}
+
+func (c *Cluster) setStatus(status ClusterStatus) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.status = status
+}
+
+// Matches reports whether this cluster's labels satisfy selector.
+func (c *Cluster) Matches(selector map[string]string) bool {
+	for k, v := range selector {
+		if c.Labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// ClusterStore keeps the set of member clusters an AEnvHubEnv may be
+// scheduled onto, similar in spirit to a federation ClusterController.
+type ClusterStore struct {
+	mu       sync.RWMutex
+	clusters map[string]*Cluster
+}
+
+// NewClusterStore creates an empty ClusterStore.
+func NewClusterStore() *ClusterStore {
+	return &ClusterStore{clusters: make(map[string]*Cluster)}
+}
+
+// Register adds or replaces a member cluster.
+func (s *ClusterStore) Register(c *Cluster) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clusters[c.Name] = c
+}
+
+// Get returns the named cluster, if registered.
+func (s *ClusterStore) Get(name string) (*Cluster, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, ok := s.clusters[name]
+	return c, ok
+}
+
+// List returns every registered cluster.
+func (s *ClusterStore) List() []*Cluster {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]*Cluster, 0, len(s.clusters))
+	for _, c := range s.clusters {
+		out = append(out, c)
+	}
+	return out
+}
+
+// Len reports how many clusters are registered.
+func (s *ClusterStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.clusters)
+}
+
+// StartHealthChecks periodically refreshes each cluster's Status by calling
+// Discovery().ServerVersion() against its clientset, modeled on a federation
+// cluster controller's periodic status update loop.
+func (s *ClusterStore) StartHealthChecks(interval time.Duration, stopCh <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.checkAll()
+			case <-stopCh:
+				return
+			}
+		}
+	}()
+}
+
+func (s *ClusterStore) checkAll() {
+	for _, c := range s.List() {
+		cs, err := c.Clientset()
+		if err != nil {
+			klog.Warningf("cluster %s: failed to build clientset: %v", c.Name, err)
+			c.setStatus(ClusterStatusOffline)
+			continue
+		}
+		if _, err := cs.Discovery().ServerVersion(); err != nil {
+			klog.Warningf("cluster %s: health check failed: %v", c.Name, err)
+			c.setStatus(ClusterStatusOffline)
+			continue
+		}
+		c.setStatus(ClusterStatusReady)
+	}
+}
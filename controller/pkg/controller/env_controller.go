@@ -0,0 +1,293 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package controller hosts the controller-runtime reconcilers registered
+// against the shared manager built in controller/cmd/main.go.
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"controller/pkg/aenvhub_http_server"
+	aenvv1alpha1 "controller/pkg/apis/aenv/v1alpha1"
+	"controller/pkg/installer"
+	"controller/pkg/model"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// envFinalizer guards Envs that own a multi-resource rollout so Reconcile
+// gets a chance to tear resources down, in reverse order, before the Env is removed.
+const envFinalizer = "aenv.io/resources-finalizer"
+
+// EnvReconciler drives an Env through the EnvStatus state machine defined in
+// controller/pkg/model by creating and watching the backing Pod, or, when
+// Spec.Resources is set, by driving an ordered multi-resource rollout
+// through Installer instead.
+type EnvReconciler struct {
+	client.Client
+	Installer *installer.Installer
+}
+
+// Add wires EnvReconciler into the given manager and is meant to be invoked
+// from controller/cmd/main.go's AddToManagerFuncs.
+func Add(mgr manager.Manager) error {
+	dynamicClient, err := dynamic.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		return fmt.Errorf("unable to create dynamic client for installer: %v", err)
+	}
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(mgr.GetConfig())
+	if err != nil {
+		return fmt.Errorf("unable to create discovery client for installer: %v", err)
+	}
+
+	r := &EnvReconciler{Client: mgr.GetClient(), Installer: installer.New(dynamicClient, discoveryClient)}
+
+	c, err := controller.New("env-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return fmt.Errorf("unable to create env controller: %v", err)
+	}
+
+	if err := c.Watch(&source.Kind{Type: &aenvv1alpha1.Env{}}, &handler.EnqueueRequestForObject{}); err != nil {
+		return fmt.Errorf("unable to watch Env: %v", err)
+	}
+	if err := c.Watch(&source.Kind{Type: &corev1.Pod{}}, &handler.EnqueueRequestForOwner{
+		OwnerType:    &aenvv1alpha1.Env{},
+		IsController: true,
+	}); err != nil {
+		return fmt.Errorf("unable to watch owned Pods: %v", err)
+	}
+
+	return nil
+}
+
+// Reconcile implements reconcile.Reconciler. It materializes the Pod backing
+// an Env and keeps .status in sync with the pod's phase, moving the Env
+// through Pending -> Creating -> Ready (or Released/Failed). Envs that
+// carry Spec.Resources instead drive an ordered multi-resource rollout
+// through Installer, gated by envFinalizer so delete tears resources down in
+// reverse order before the Env itself is removed.
+func (r *EnvReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	var env aenvv1alpha1.Env
+	if err := r.Get(ctx, req.NamespacedName, &env); err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	if !env.DeletionTimestamp.IsZero() {
+		return r.finalizeEnv(ctx, &env)
+	}
+
+	if len(env.Spec.Resources) > 0 {
+		if !hasFinalizer(&env, envFinalizer) {
+			env.Finalizers = append(env.Finalizers, envFinalizer)
+			if err := r.Update(ctx, &env); err != nil {
+				return reconcile.Result{}, err
+			}
+		}
+		return reconcile.Result{}, r.reconcileResources(ctx, &env)
+	}
+
+	podName := types.NamespacedName{Namespace: env.Namespace, Name: env.Name}
+	var pod corev1.Pod
+	err := r.Get(ctx, podName, &pod)
+	switch {
+	case errors.IsNotFound(err):
+		if syncErr := r.updateStatus(ctx, &env, model.EnvStatusPending, "", "", ""); syncErr != nil {
+			return reconcile.Result{}, syncErr
+		}
+		if createErr := r.createPodForEnv(ctx, &env); createErr != nil {
+			_ = r.updateStatus(ctx, &env, model.EnvStatusFailed, "", "", createErr.Error())
+			return reconcile.Result{}, createErr
+		}
+		return reconcile.Result{}, r.updateStatus(ctx, &env, model.EnvStatusCreating, env.Name, "", "")
+	case err != nil:
+		return reconcile.Result{}, err
+	}
+
+	return reconcile.Result{}, r.syncStatusFromPod(ctx, &env, &pod)
+}
+
+// createPodForEnv renders the pod template the same way the legacy HTTP
+// handler did and sets env as its controller owner.
+func (r *EnvReconciler) createPodForEnv(ctx context.Context, env *aenvv1alpha1.Env) error {
+	templateType := aenvhub_http_server.SingleContainerTemplate
+	if v, ok := env.Spec.DeployConfig["podTemplate"]; ok && v != "" {
+		templateType = v
+	}
+
+	pod := aenvhub_http_server.LoadPodTemplateFromYaml(templateType)
+	pod.Name = env.Name
+	pod.Namespace = env.Namespace
+
+	legacyEnv := toModelEnv(env)
+	aenvhub_http_server.MergePodImage(pod, legacyEnv)
+
+	if err := controllerutil.SetControllerReference(env, pod, r.Scheme()); err != nil {
+		return fmt.Errorf("unable to set owner reference on pod %s: %v", pod.Name, err)
+	}
+
+	if err := r.Create(ctx, pod); err != nil && !errors.IsAlreadyExists(err) {
+		return fmt.Errorf("unable to create pod for env %s: %v", env.Name, err)
+	}
+	klog.Infof("env %s/%s: created backing pod %s", env.Namespace, env.Name, pod.Name)
+	return nil
+}
+
+// syncStatusFromPod maps the pod's current phase onto the Env state machine.
+func (r *EnvReconciler) syncStatusFromPod(ctx context.Context, env *aenvv1alpha1.Env, pod *corev1.Pod) error {
+	status := model.EnvStatusCreating
+	switch pod.Status.Phase {
+	case corev1.PodRunning:
+		status = model.EnvStatusReady
+	case corev1.PodSucceeded:
+		status = model.EnvStatusReleased
+	case corev1.PodFailed:
+		status = model.EnvStatusFailed
+	case corev1.PodPending:
+		status = model.EnvStatusCreating
+	}
+	return r.updateStatus(ctx, env, status, pod.Name, pod.Status.PodIP, "")
+}
+
+// reconcileResources drives the ordered multi-resource rollout for an Env
+// that sets Spec.Resources, recording which resource blocked progress (if
+// any) on the Env's status instead of creating a single pod directly.
+func (r *EnvReconciler) reconcileResources(ctx context.Context, env *aenvv1alpha1.Env) error {
+	if err := r.Installer.Install(ctx, env.Namespace, toResourceSpecs(env.Spec.Resources)); err != nil {
+		if blocked, ok := err.(*installer.BlockedError); ok {
+			return r.updateStatus(ctx, env, model.EnvStatusFailed, "", "", blocked.Error())
+		}
+		return err
+	}
+	return r.updateStatus(ctx, env, model.EnvStatusReady, "", "", "")
+}
+
+// finalizeEnv tears down a Resources-backed rollout in reverse order before
+// letting the Env itself be removed.
+func (r *EnvReconciler) finalizeEnv(ctx context.Context, env *aenvv1alpha1.Env) (reconcile.Result, error) {
+	if !hasFinalizer(env, envFinalizer) {
+		return reconcile.Result{}, nil
+	}
+
+	if len(env.Spec.Resources) > 0 {
+		if err := r.Installer.Teardown(ctx, env.Namespace, toResourceSpecs(env.Spec.Resources)); err != nil {
+			klog.Errorf("env %s/%s: teardown failed, will retry: %v", env.Namespace, env.Name, err)
+			return reconcile.Result{}, err
+		}
+	}
+
+	env.Finalizers = removeFinalizer(env.Finalizers, envFinalizer)
+	if err := r.Update(ctx, env); err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{}, nil
+}
+
+func hasFinalizer(env *aenvv1alpha1.Env, name string) bool {
+	for _, f := range env.Finalizers {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+func removeFinalizer(finalizers []string, name string) []string {
+	out := finalizers[:0]
+	for _, f := range finalizers {
+		if f != name {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// toResourceSpecs converts the CRD's raw-manifest Resources into the model
+// shape Installer operates on, skipping (and logging) any manifest that
+// doesn't unmarshal cleanly rather than failing the whole rollout.
+func toResourceSpecs(resources []aenvv1alpha1.Resource) []model.ResourceSpec {
+	specs := make([]model.ResourceSpec, 0, len(resources))
+	for _, r := range resources {
+		var manifest map[string]interface{}
+		if err := json.Unmarshal(r.Manifest.Raw, &manifest); err != nil {
+			klog.Errorf("skipping resource with invalid manifest: %v", err)
+			continue
+		}
+		specs = append(specs, model.ResourceSpec{
+			Manifest:  manifest,
+			DependsOn: r.DependsOn,
+			WaitFor:   model.WaitForCondition(r.WaitFor),
+		})
+	}
+	return specs
+}
+
+// updateStatus patches the Env status subresource.
+func (r *EnvReconciler) updateStatus(ctx context.Context, env *aenvv1alpha1.Env, status model.EnvStatus, podName, podIP, reason string) error {
+	env.Status.Phase = model.EnvStatusNameByStatus(status)
+	if podName != "" {
+		env.Status.PodName = podName
+	}
+	if podIP != "" {
+		env.Status.PodIP = podIP
+	}
+	env.Status.Reason = reason
+	env.Status.ObservedGeneration = env.Generation
+	if err := r.Status().Update(ctx, env); err != nil {
+		return fmt.Errorf("unable to update status for env %s/%s: %v", env.Namespace, env.Name, err)
+	}
+	return nil
+}
+
+// toModelEnv adapts the CRD spec to the legacy model.AEnvHubEnv shape that
+// MergePodImage/applyConfig already know how to consume.
+func toModelEnv(env *aenvv1alpha1.Env) *model.AEnvHubEnv {
+	artifacts := make([]model.Artifact, 0, len(env.Spec.Artifacts))
+	for _, a := range env.Spec.Artifacts {
+		artifacts = append(artifacts, model.Artifact{Id: a.ID, Type: a.Type, Content: a.Content})
+	}
+	deployConfig := make(map[string]interface{}, len(env.Spec.DeployConfig))
+	for k, v := range env.Spec.DeployConfig {
+		deployConfig[k] = v
+	}
+	return &model.AEnvHubEnv{
+		ID:           string(env.UID),
+		Name:         env.Name,
+		Description:  env.Spec.Description,
+		Version:      env.Spec.Version,
+		Tags:         env.Spec.Tags,
+		CodeURL:      env.Spec.CodeURL,
+		Artifacts:    artifacts,
+		DeployConfig: deployConfig,
+	}
+}
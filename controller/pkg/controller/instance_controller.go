@@ -0,0 +1,258 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"controller/pkg/aenvhub_http_server"
+	aenvv1alpha1 "controller/pkg/apis/aenv/v1alpha1"
+	"controller/pkg/model"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// InstanceReconciler materializes the Pod backing an AEnvInstance, replacing
+// the imperative create-and-forget path AEnvPodHandler.createPod used to own
+// directly. It re-renders the pod (MergePodImage/applyConfig) whenever the
+// spec changes, keeps .status in sync with the pod's phase, node and IP, and
+// deletes AEnvInstances whose TTL has elapsed.
+type InstanceReconciler struct {
+	client.Client
+}
+
+// AddInstanceController wires InstanceReconciler into the given manager and
+// is meant to be invoked from controller/cmd/main.go's AddToManagerFuncs,
+// alongside env-controller.
+func AddInstanceController(mgr manager.Manager) error {
+	r := &InstanceReconciler{Client: mgr.GetClient()}
+
+	c, err := controller.New("aenvinstance-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return fmt.Errorf("unable to create aenvinstance controller: %v", err)
+	}
+
+	if err := c.Watch(&source.Kind{Type: &aenvv1alpha1.AEnvInstance{}}, &handler.EnqueueRequestForObject{}); err != nil {
+		return fmt.Errorf("unable to watch AEnvInstance: %v", err)
+	}
+	if err := c.Watch(&source.Kind{Type: &corev1.Pod{}}, &handler.EnqueueRequestForOwner{
+		OwnerType:    &aenvv1alpha1.AEnvInstance{},
+		IsController: true,
+	}); err != nil {
+		return fmt.Errorf("unable to watch owned Pods: %v", err)
+	}
+
+	return nil
+}
+
+// Reconcile implements reconcile.Reconciler.
+func (r *InstanceReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	var instance aenvv1alpha1.AEnvInstance
+	if err := r.Get(ctx, req.NamespacedName, &instance); err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	if !instance.DeletionTimestamp.IsZero() {
+		// The backing pod is owned by this instance, so it cascades away on
+		// its own; nothing left for the reconciler to do.
+		return reconcile.Result{}, nil
+	}
+
+	if ttlExpired(&instance) {
+		klog.Infof("aenvinstance %s/%s: ttl elapsed, deleting", instance.Namespace, instance.Name)
+		if err := r.Delete(ctx, &instance); err != nil && !errors.IsNotFound(err) {
+			return reconcile.Result{}, err
+		}
+		return reconcile.Result{}, nil
+	}
+
+	podName := types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}
+	var pod corev1.Pod
+	err := r.Get(ctx, podName, &pod)
+	switch {
+	case errors.IsNotFound(err):
+		if createErr := r.createPodForInstance(ctx, &instance); createErr != nil {
+			_ = r.updateStatus(ctx, &instance, model.EnvStatusFailed, "", "", "", createErr.Error())
+			return reconcile.Result{}, createErr
+		}
+		return r.requeueResult(&instance), r.updateStatus(ctx, &instance, model.EnvStatusCreating, instance.Name, "", "", "")
+	case err != nil:
+		return reconcile.Result{}, err
+	}
+
+	if instance.Generation != instance.Status.ObservedGeneration {
+		if updateErr := r.updatePodForInstance(ctx, &instance, &pod); updateErr != nil {
+			_ = r.updateStatus(ctx, &instance, model.EnvStatusFailed, "", "", "", updateErr.Error())
+			return reconcile.Result{}, updateErr
+		}
+	}
+
+	if syncErr := r.syncStatusFromPod(ctx, &instance, &pod); syncErr != nil {
+		return reconcile.Result{}, syncErr
+	}
+	return r.requeueResult(&instance), nil
+}
+
+// createPodForInstance renders the pod template the same way
+// AEnvPodHandler.createPod did and sets instance as its controller owner.
+func (r *InstanceReconciler) createPodForInstance(ctx context.Context, instance *aenvv1alpha1.AEnvInstance) error {
+	templateType := aenvhub_http_server.SingleContainerTemplate
+	if v, ok := instance.Spec.DeployConfig["podTemplate"]; ok && v != "" {
+		templateType = v
+	}
+
+	pod := aenvhub_http_server.LoadPodTemplateFromYaml(templateType)
+	pod.Name = instance.Name
+	pod.Namespace = instance.Namespace
+
+	legacyEnv := toModelEnvFromInstance(instance)
+	aenvhub_http_server.MergePodImage(pod, legacyEnv)
+
+	if instance.Spec.TTL != "" {
+		if pod.Labels == nil {
+			pod.Labels = make(map[string]string)
+		}
+		pod.Labels["aenv-ttl"] = instance.Spec.TTL
+		pod.Finalizers = append(pod.Finalizers, aenvhub_http_server.TTLFinalizer)
+	}
+
+	if err := controllerutil.SetControllerReference(instance, pod, r.Scheme()); err != nil {
+		return fmt.Errorf("unable to set owner reference on pod %s: %v", pod.Name, err)
+	}
+
+	if err := r.Create(ctx, pod); err != nil && !errors.IsAlreadyExists(err) {
+		return fmt.Errorf("unable to create pod for instance %s: %v", instance.Name, err)
+	}
+	klog.Infof("aenvinstance %s/%s: created backing pod %s", instance.Namespace, instance.Name, pod.Name)
+	return nil
+}
+
+// updatePodForInstance re-renders pod's image, resource, and env config from
+// instance's current spec and patches it in place, so editing
+// AEnvInstance.Spec.DeployConfig (e.g. bumping the image/version) actually
+// takes effect on the already-running pod instead of being silently ignored
+// until the instance is deleted and recreated. Mirrors
+// createPodForInstance's render step; MergePodImage already folds applyConfig
+// in per-container.
+func (r *InstanceReconciler) updatePodForInstance(ctx context.Context, instance *aenvv1alpha1.AEnvInstance, pod *corev1.Pod) error {
+	legacyEnv := toModelEnvFromInstance(instance)
+	aenvhub_http_server.MergePodImage(pod, legacyEnv)
+
+	if err := r.Update(ctx, pod); err != nil {
+		return fmt.Errorf("unable to update pod %s for instance %s spec change: %v", pod.Name, instance.Name, err)
+	}
+	klog.Infof("aenvinstance %s/%s: re-applied spec changes to pod %s", instance.Namespace, instance.Name, pod.Name)
+	return nil
+}
+
+// syncStatusFromPod maps the pod's current phase onto the AEnvInstance state machine.
+func (r *InstanceReconciler) syncStatusFromPod(ctx context.Context, instance *aenvv1alpha1.AEnvInstance, pod *corev1.Pod) error {
+	status := model.EnvStatusCreating
+	switch pod.Status.Phase {
+	case corev1.PodRunning:
+		status = model.EnvStatusReady
+	case corev1.PodSucceeded:
+		status = model.EnvStatusReleased
+	case corev1.PodFailed:
+		status = model.EnvStatusFailed
+	case corev1.PodPending:
+		status = model.EnvStatusCreating
+	}
+	return r.updateStatus(ctx, instance, status, pod.Name, pod.Status.PodIP, pod.Spec.NodeName, "")
+}
+
+// updateStatus patches the AEnvInstance status subresource.
+func (r *InstanceReconciler) updateStatus(ctx context.Context, instance *aenvv1alpha1.AEnvInstance, status model.EnvStatus, podName, podIP, nodeName, reason string) error {
+	instance.Status.Phase = model.EnvStatusNameByStatus(status)
+	if podName != "" {
+		instance.Status.PodName = podName
+	}
+	if podIP != "" {
+		instance.Status.PodIP = podIP
+	}
+	if nodeName != "" {
+		instance.Status.NodeName = nodeName
+	}
+	instance.Status.Reason = reason
+	instance.Status.ObservedGeneration = instance.Generation
+	if err := r.Status().Update(ctx, instance); err != nil {
+		return fmt.Errorf("unable to update status for aenvinstance %s/%s: %v", instance.Namespace, instance.Name, err)
+	}
+	return nil
+}
+
+// requeueResult schedules the next TTL check for instances that carry a TTL,
+// so expiry is enforced even without a triggering watch event.
+func (r *InstanceReconciler) requeueResult(instance *aenvv1alpha1.AEnvInstance) reconcile.Result {
+	if instance.Spec.TTL == "" {
+		return reconcile.Result{}
+	}
+	if ttl, err := time.ParseDuration(instance.Spec.TTL); err == nil {
+		return reconcile.Result{RequeueAfter: ttl}
+	}
+	return reconcile.Result{}
+}
+
+// ttlExpired reports whether instance's TTL, measured from its creation
+// timestamp, has elapsed. Deletion goes through a normal r.Delete call
+// (which sets metadata.deletionTimestamp) rather than patching the field
+// directly, since the apiserver rejects direct writes to it.
+func ttlExpired(instance *aenvv1alpha1.AEnvInstance) bool {
+	if instance.Spec.TTL == "" {
+		return false
+	}
+	ttl, err := time.ParseDuration(instance.Spec.TTL)
+	if err != nil {
+		klog.Errorf("aenvinstance %s/%s: invalid ttl %q: %v", instance.Namespace, instance.Name, instance.Spec.TTL, err)
+		return false
+	}
+	return !instance.CreationTimestamp.Add(ttl).After(time.Now())
+}
+
+// toModelEnvFromInstance adapts the CRD spec to the legacy model.AEnvHubEnv
+// shape that MergePodImage/applyConfig already know how to consume.
+func toModelEnvFromInstance(instance *aenvv1alpha1.AEnvInstance) *model.AEnvHubEnv {
+	artifacts := make([]model.Artifact, 0, len(instance.Spec.Artifacts))
+	for _, a := range instance.Spec.Artifacts {
+		artifacts = append(artifacts, model.Artifact{Id: a.ID, Type: a.Type, Content: a.Content})
+	}
+	deployConfig := make(map[string]interface{}, len(instance.Spec.DeployConfig))
+	for k, v := range instance.Spec.DeployConfig {
+		deployConfig[k] = v
+	}
+	return &model.AEnvHubEnv{
+		ID:           string(instance.UID),
+		Name:         instance.Spec.EnvName,
+		Artifacts:    artifacts,
+		DeployConfig: deployConfig,
+	}
+}
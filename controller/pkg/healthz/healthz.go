@@ -0,0 +1,145 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package healthz provides named, composable health checks served as
+// /livez, /readyz, and /healthz (with per-check /readyz/<name> sub-paths),
+// replacing a single hardcoded "leader or not" readiness probe.
+package healthz
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Checker is a single named health check.
+type Checker interface {
+	Name() string
+	Check() error
+}
+
+type namedCheck struct {
+	name string
+	fn   func() error
+}
+
+func (c *namedCheck) Name() string { return c.name }
+func (c *namedCheck) Check() error { return c.fn() }
+
+// NamedCheck adapts a plain func() error into a Checker.
+func NamedCheck(name string, fn func() error) Checker {
+	return &namedCheck{name: name, fn: fn}
+}
+
+// checkResult is the verbose JSON shape for one check's outcome.
+type checkResult struct {
+	Name  string `json:"name"`
+	Ok    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// Handler serves health endpoints backed by a fixed set of named checks.
+type Handler struct {
+	checks []Checker
+}
+
+// NewHandler builds a Handler over the given checks.
+func NewHandler(checks ...Checker) *Handler {
+	return &Handler{checks: checks}
+}
+
+// Livez, Readyz and Healthz all share the same evaluation logic today
+// (there is a single set of checks, not separate liveness/readiness sets);
+// they are exposed as distinct endpoints so callers and probes can depend on
+// the conventional Kubernetes names.
+func (h *Handler) Livez() http.HandlerFunc   { return h.serve("/livez") }
+func (h *Handler) Readyz() http.HandlerFunc  { return h.serve("/readyz") }
+func (h *Handler) Healthz() http.HandlerFunc { return h.serve("/healthz") }
+
+// serve returns a handler that supports:
+//   - GET <prefix>                      -- aggregate status, "ok"/"not ok" or JSON with ?verbose=1
+//   - GET <prefix>?exclude=a,b          -- skip named checks a and b
+//   - GET <prefix>/<name>               -- run a single named check
+func (h *Handler) serve(prefix string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, prefix), "/")
+		if name != "" {
+			h.serveOne(w, name)
+			return
+		}
+		h.serveAll(w, r)
+	}
+}
+
+func (h *Handler) serveOne(w http.ResponseWriter, name string) {
+	for _, c := range h.checks {
+		if c.Name() != name {
+			continue
+		}
+		if err := c.Check(); err != nil {
+			http.Error(w, fmt.Sprintf("%s: %v", name, err), http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok"))
+		return
+	}
+	http.Error(w, fmt.Sprintf("unknown check %q", name), http.StatusNotFound)
+}
+
+func (h *Handler) serveAll(w http.ResponseWriter, r *http.Request) {
+	excluded := map[string]bool{}
+	for _, n := range strings.Split(r.URL.Query().Get("exclude"), ",") {
+		if n != "" {
+			excluded[n] = true
+		}
+	}
+
+	results := make([]checkResult, 0, len(h.checks))
+	allOk := true
+	for _, c := range h.checks {
+		if excluded[c.Name()] {
+			continue
+		}
+		res := checkResult{Name: c.Name(), Ok: true}
+		if err := c.Check(); err != nil {
+			res.Ok = false
+			res.Error = err.Error()
+			allOk = false
+		}
+		results = append(results, res)
+	}
+
+	status := http.StatusOK
+	if !allOk {
+		status = http.StatusServiceUnavailable
+	}
+
+	if r.URL.Query().Get("verbose") == "1" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(results)
+		return
+	}
+
+	w.WriteHeader(status)
+	if allOk {
+		_, _ = w.Write([]byte("ok"))
+	} else {
+		_, _ = w.Write([]byte("not ok"))
+	}
+}
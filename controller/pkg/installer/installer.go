@@ -0,0 +1,286 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package installer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"controller/pkg/model"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/klog"
+)
+
+// fieldManager identifies this controller's writes to resources it applies
+// via server-side apply, so repeated Installs don't fight other owners.
+const fieldManager = "aenv-controller"
+
+// BlockedError reports which resource stalled an Install/Teardown so the
+// caller can record it on the owning Env's status.
+type BlockedError struct {
+	Resource string
+	Reason   string
+}
+
+func (e *BlockedError) Error() string {
+	return fmt.Sprintf("rollout blocked on %s: %s", e.Resource, e.Reason)
+}
+
+// Installer applies an AEnvHubEnv's Resources in dependency order using
+// server-side apply through the dynamic client, gating each wave on its
+// readiness predicate before moving to the next.
+type Installer struct {
+	Dynamic    dynamic.Interface
+	RESTMapper meta.RESTMapper
+
+	// WaveTimeout bounds how long Install waits for a single wave to become ready.
+	WaveTimeout time.Duration
+	// PollInterval controls how often readiness is re-checked within a wave.
+	PollInterval time.Duration
+}
+
+// New builds an Installer from a dynamic client and a discovery client used
+// to resolve each manifest's GroupVersionKind to its REST resource.
+func New(dynamicClient dynamic.Interface, discoveryClient discovery.DiscoveryInterface) *Installer {
+	groupResources, err := restmapper.GetAPIGroupResources(discoveryClient)
+	if err != nil {
+		klog.Warningf("installer: failed to discover API group resources, resource mapping will be degraded: %v", err)
+	}
+	return &Installer{
+		Dynamic:      dynamicClient,
+		RESTMapper:   restmapper.NewDiscoveryRESTMapper(groupResources),
+		WaveTimeout:  2 * time.Minute,
+		PollInterval: 2 * time.Second,
+	}
+}
+
+// Install plans resources into waves and, for each wave in order, applies
+// every resource then blocks until the wave's readiness predicate holds or
+// WaveTimeout elapses.
+func (in *Installer) Install(ctx context.Context, namespace string, resources []model.ResourceSpec) error {
+	waves, err := Plan(resources)
+	if err != nil {
+		return fmt.Errorf("unable to plan rollout: %v", err)
+	}
+
+	for i, wave := range waves {
+		applied := make([]*unstructured.Unstructured, 0, len(wave))
+		for _, spec := range wave {
+			obj, err := in.apply(ctx, namespace, spec)
+			if err != nil {
+				return &BlockedError{Resource: describe(spec), Reason: err.Error()}
+			}
+			applied = append(applied, obj)
+		}
+
+		klog.Infof("installer: wave %d/%d applied (%d resource(s)), waiting for readiness", i+1, len(waves), len(applied))
+		if err := in.waitForWave(ctx, wave, applied); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Teardown deletes resources in reverse install order (last wave first), so
+// e.g. a Deployment is removed before the ConfigMap it mounts.
+func (in *Installer) Teardown(ctx context.Context, namespace string, resources []model.ResourceSpec) error {
+	waves, err := Plan(resources)
+	if err != nil {
+		return fmt.Errorf("unable to plan teardown: %v", err)
+	}
+
+	for i := len(waves) - 1; i >= 0; i-- {
+		for _, spec := range waves[i] {
+			if err := in.delete(ctx, namespace, spec); err != nil {
+				return &BlockedError{Resource: describe(spec), Reason: err.Error()}
+			}
+		}
+	}
+	return nil
+}
+
+func (in *Installer) resourceClientFor(obj *unstructured.Unstructured, namespace string) (dynamic.ResourceInterface, error) {
+	gvk := obj.GroupVersionKind()
+	mapping, err := in.RESTMapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, fmt.Errorf("unable to map %s: %v", gvk, err)
+	}
+
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		return in.Dynamic.Resource(mapping.Resource), nil
+	}
+
+	ns := obj.GetNamespace()
+	if ns == "" {
+		ns = namespace
+	}
+	return in.Dynamic.Resource(mapping.Resource).Namespace(ns), nil
+}
+
+func (in *Installer) apply(ctx context.Context, namespace string, spec model.ResourceSpec) (*unstructured.Unstructured, error) {
+	obj := &unstructured.Unstructured{Object: spec.Manifest}
+	client, err := in.resourceClientFor(obj, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := obj.MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal manifest: %v", err)
+	}
+
+	force := true
+	applied, err := client.Patch(ctx, obj.GetName(), types.ApplyPatchType, data, metav1.PatchOptions{
+		FieldManager: fieldManager,
+		Force:        &force,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("server-side apply failed: %v", err)
+	}
+	return applied, nil
+}
+
+func (in *Installer) delete(ctx context.Context, namespace string, spec model.ResourceSpec) error {
+	obj := &unstructured.Unstructured{Object: spec.Manifest}
+	client, err := in.resourceClientFor(obj, namespace)
+	if err != nil {
+		return err
+	}
+
+	if err := client.Delete(ctx, obj.GetName(), metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("unable to delete %s: %v", describe(spec), err)
+	}
+	klog.Infof("installer: deleted %s", describe(spec))
+	return nil
+}
+
+// waitForWave polls every resource applied in this wave until each satisfies
+// its WaitFor condition or WaveTimeout elapses.
+func (in *Installer) waitForWave(ctx context.Context, specs []model.ResourceSpec, applied []*unstructured.Unstructured) error {
+	deadline := time.Now().Add(in.WaveTimeout)
+
+	type pendingResource struct {
+		key     string
+		obj     *unstructured.Unstructured
+		waitFor model.WaitForCondition
+	}
+	pending := make([]pendingResource, len(applied))
+	for i, obj := range applied {
+		pending[i] = pendingResource{key: describe(specs[i]), obj: obj, waitFor: specs[i].WaitFor}
+	}
+
+	for {
+		remaining := pending[:0]
+		for _, p := range pending {
+			ready, err := in.isReady(ctx, p.obj, p.waitFor)
+			if err != nil {
+				return &BlockedError{Resource: p.key, Reason: err.Error()}
+			}
+			if !ready {
+				remaining = append(remaining, p)
+			}
+		}
+		pending = remaining
+
+		if len(pending) == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			stuck := make([]string, 0, len(pending))
+			for _, p := range pending {
+				stuck = append(stuck, p.key)
+			}
+			return &BlockedError{Resource: strings.Join(stuck, ", "), Reason: "timed out waiting for readiness"}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(in.PollInterval):
+		}
+	}
+}
+
+// isReady re-fetches obj and evaluates condition against its live status.
+func (in *Installer) isReady(ctx context.Context, obj *unstructured.Unstructured, condition model.WaitForCondition) (bool, error) {
+	if condition == "" {
+		return true, nil
+	}
+
+	client, err := in.resourceClientFor(obj, obj.GetNamespace())
+	if err != nil {
+		return false, err
+	}
+	current, err := client.Get(ctx, obj.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return false, fmt.Errorf("unable to refresh %s/%s: %v", obj.GroupVersionKind().Kind, obj.GetName(), err)
+	}
+
+	switch condition {
+	case model.WaitForJobComplete:
+		return conditionTrue(current, "Complete"), nil
+	case model.WaitForAvailable:
+		return conditionTrue(current, "Available"), nil
+	case model.WaitForReady:
+		return replicasReady(current) || conditionTrue(current, "Ready"), nil
+	default:
+		return true, nil
+	}
+}
+
+// conditionTrue reports whether obj's status.conditions contains a True
+// condition of the given type (the shape Deployments, Jobs, etc. all share).
+func conditionTrue(obj *unstructured.Unstructured, condType string) bool {
+	conditions, found, err := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	if err != nil || !found {
+		return false
+	}
+	for _, c := range conditions {
+		cond, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if cond["type"] == condType && cond["status"] == "True" {
+			return true
+		}
+	}
+	return false
+}
+
+// replicasReady treats Deployment/StatefulSet-shaped resources as ready once
+// status.readyReplicas meets spec.replicas (defaulting to 1 when unset).
+func replicasReady(obj *unstructured.Unstructured) bool {
+	replicas, found, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+	if !found {
+		replicas = 1
+	}
+	ready, found, _ := unstructured.NestedInt64(obj.Object, "status", "readyReplicas")
+	if !found {
+		return false
+	}
+	return ready >= replicas
+}
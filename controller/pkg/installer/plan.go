@@ -0,0 +1,169 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package installer applies an AEnvHubEnv's Resources in dependency order,
+// grouping them into waves that are rolled out and gated on readiness one
+// at a time, and tears them back down in reverse order on delete.
+package installer
+
+import (
+	"fmt"
+
+	"controller/pkg/model"
+)
+
+// defaultKindPriority buckets well-known kinds into the order real
+// environments usually need them installed: namespaces and CRDs first, then
+// config/secrets/storage, then identity/RBAC, then services, then the
+// workloads that consume all of the above, then ingress.
+var defaultKindPriority = map[string]int{
+	"Namespace":                0,
+	"CustomResourceDefinition": 1,
+	"ConfigMap":                2,
+	"Secret":                   2,
+	"PersistentVolumeClaim":    3,
+	"ServiceAccount":           4,
+	"Role":                     4,
+	"RoleBinding":              4,
+	"ClusterRole":              4,
+	"ClusterRoleBinding":       4,
+	"Service":                  5,
+	"Deployment":               6,
+	"StatefulSet":              6,
+	"Job":                      6,
+	"Pod":                      6,
+	"Ingress":                  7,
+	"Route":                    7,
+}
+
+// unknownKindPriority treats anything not in defaultKindPriority like a
+// workload: after config/identity/service tiers, before ingress.
+const unknownKindPriority = 6
+
+func kindPriority(kind string) int {
+	if p, ok := defaultKindPriority[kind]; ok {
+		return p
+	}
+	return unknownKindPriority
+}
+
+// resourceKey identifies a resource as "kind/name" for DependsOn references.
+func resourceKey(r model.ResourceSpec) (string, error) {
+	kind, _ := r.Manifest["kind"].(string)
+	metadata, _ := r.Manifest["metadata"].(map[string]interface{})
+	var name string
+	if metadata != nil {
+		name, _ = metadata["name"].(string)
+	}
+	if kind == "" || name == "" {
+		return "", fmt.Errorf("resource manifest missing kind or metadata.name")
+	}
+	return kind + "/" + name, nil
+}
+
+// describe returns a best-effort "kind/name" label for error messages, even
+// for a manifest too malformed for resourceKey to resolve.
+func describe(r model.ResourceSpec) string {
+	if key, err := resourceKey(r); err == nil {
+		return key
+	}
+	return "<malformed resource>"
+}
+
+type planNode struct {
+	spec     model.ResourceSpec
+	priority int
+}
+
+// Plan topologically sorts resources into install waves: the kind-priority
+// defaults set a resource's minimum wave, and DependsOn edges push it later
+// still, so every dependency is installed (and ready) in an earlier wave
+// than anything that depends on it.
+func Plan(resources []model.ResourceSpec) ([][]model.ResourceSpec, error) {
+	nodes := make(map[string]*planNode, len(resources))
+	keys := make([]string, 0, len(resources))
+	for _, r := range resources {
+		key, err := resourceKey(r)
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := nodes[key]; exists {
+			return nil, fmt.Errorf("duplicate resource %s in rollout", key)
+		}
+		kind, _ := r.Manifest["kind"].(string)
+		nodes[key] = &planNode{spec: r, priority: kindPriority(kind)}
+		keys = append(keys, key)
+	}
+	for _, key := range keys {
+		for _, dep := range nodes[key].spec.DependsOn {
+			if _, ok := nodes[dep]; !ok {
+				return nil, fmt.Errorf("resource %s depends on unknown resource %q", key, dep)
+			}
+		}
+	}
+
+	waves := make(map[string]int, len(keys))
+	inProgress := make(map[string]bool, len(keys))
+	var waveOf func(key string) (int, error)
+	waveOf = func(key string) (int, error) {
+		if w, ok := waves[key]; ok {
+			return w, nil
+		}
+		if inProgress[key] {
+			return 0, fmt.Errorf("dependency cycle detected at resource %s", key)
+		}
+		inProgress[key] = true
+		defer delete(inProgress, key)
+
+		w := nodes[key].priority
+		for _, dep := range nodes[key].spec.DependsOn {
+			depWave, err := waveOf(dep)
+			if err != nil {
+				return 0, err
+			}
+			if depWave+1 > w {
+				w = depWave + 1
+			}
+		}
+		waves[key] = w
+		return w, nil
+	}
+
+	maxWave := 0
+	for _, key := range keys {
+		w, err := waveOf(key)
+		if err != nil {
+			return nil, err
+		}
+		if w > maxWave {
+			maxWave = w
+		}
+	}
+
+	byWave := make([][]model.ResourceSpec, maxWave+1)
+	for _, key := range keys {
+		w := waves[key]
+		byWave[w] = append(byWave[w], nodes[key].spec)
+	}
+
+	plan := make([][]model.ResourceSpec, 0, len(byWave))
+	for _, wave := range byWave {
+		if len(wave) > 0 {
+			plan = append(plan, wave)
+		}
+	}
+	return plan, nil
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package installer
+
+import (
+	"testing"
+
+	"controller/pkg/model"
+)
+
+func resourceSpec(kind, name string, dependsOn ...string) model.ResourceSpec {
+	return model.ResourceSpec{
+		Manifest: map[string]interface{}{
+			"kind": kind,
+			"metadata": map[string]interface{}{
+				"name": name,
+			},
+		},
+		DependsOn: dependsOn,
+	}
+}
+
+func waveOf(t *testing.T, waves [][]model.ResourceSpec, key string) int {
+	t.Helper()
+	for i, wave := range waves {
+		for _, spec := range wave {
+			if k, err := resourceKey(spec); err == nil && k == key {
+				return i
+			}
+		}
+	}
+	t.Fatalf("resource %s not found in any wave", key)
+	return -1
+}
+
+func TestPlan_OrdersByKindPriorityWhenNoDependencies(t *testing.T) {
+	resources := []model.ResourceSpec{
+		resourceSpec("Deployment", "app"),
+		resourceSpec("ConfigMap", "app-config"),
+		resourceSpec("Namespace", "app-ns"),
+	}
+
+	waves, err := Plan(resources)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	nsWave := waveOf(t, waves, "Namespace/app-ns")
+	cmWave := waveOf(t, waves, "ConfigMap/app-config")
+	deployWave := waveOf(t, waves, "Deployment/app")
+
+	if !(nsWave < cmWave && cmWave < deployWave) {
+		t.Fatalf("expected Namespace < ConfigMap < Deployment, got %d, %d, %d", nsWave, cmWave, deployWave)
+	}
+}
+
+func TestPlan_DependsOnPushesResourceToLaterWave(t *testing.T) {
+	resources := []model.ResourceSpec{
+		resourceSpec("Service", "frontend-svc", "Deployment/frontend"),
+		resourceSpec("Deployment", "frontend"),
+	}
+
+	waves, err := Plan(resources)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	deployWave := waveOf(t, waves, "Deployment/frontend")
+	svcWave := waveOf(t, waves, "Service/frontend-svc")
+	if svcWave <= deployWave {
+		t.Fatalf("expected the dependent Service to land after its Deployment, got svc=%d deploy=%d", svcWave, deployWave)
+	}
+}
+
+func TestPlan_ResourcesInSameWaveWhenIndependent(t *testing.T) {
+	resources := []model.ResourceSpec{
+		resourceSpec("ConfigMap", "a"),
+		resourceSpec("ConfigMap", "b"),
+	}
+
+	waves, err := Plan(resources)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(waves) != 1 || len(waves[0]) != 2 {
+		t.Fatalf("expected both independent ConfigMaps in a single wave of 2, got %v", waves)
+	}
+}
+
+func TestPlan_DetectsDependencyCycle(t *testing.T) {
+	resources := []model.ResourceSpec{
+		resourceSpec("ConfigMap", "a", "ConfigMap/b"),
+		resourceSpec("ConfigMap", "b", "ConfigMap/a"),
+	}
+
+	if _, err := Plan(resources); err == nil {
+		t.Fatal("expected an error for a dependency cycle")
+	}
+}
+
+func TestPlan_RejectsUnknownDependency(t *testing.T) {
+	resources := []model.ResourceSpec{
+		resourceSpec("Deployment", "app", "ConfigMap/missing"),
+	}
+
+	if _, err := Plan(resources); err == nil {
+		t.Fatal("expected an error when DependsOn references an unknown resource")
+	}
+}
+
+func TestPlan_RejectsDuplicateResource(t *testing.T) {
+	resources := []model.ResourceSpec{
+		resourceSpec("ConfigMap", "a"),
+		resourceSpec("ConfigMap", "a"),
+	}
+
+	if _, err := Plan(resources); err == nil {
+		t.Fatal("expected an error for a duplicate resource key")
+	}
+}
+
+func TestPlan_RejectsMalformedManifest(t *testing.T) {
+	resources := []model.ResourceSpec{
+		{Manifest: map[string]interface{}{"kind": "ConfigMap"}},
+	}
+
+	if _, err := Plan(resources); err == nil {
+		t.Fatal("expected an error for a manifest missing metadata.name")
+	}
+}
+
+func TestPlan_TransitiveDependencyChainOrdersAllThree(t *testing.T) {
+	resources := []model.ResourceSpec{
+		resourceSpec("Job", "migrate", "ConfigMap/db-config"),
+		resourceSpec("Deployment", "app", "Job/migrate"),
+		resourceSpec("ConfigMap", "db-config"),
+	}
+
+	waves, err := Plan(resources)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cmWave := waveOf(t, waves, "ConfigMap/db-config")
+	jobWave := waveOf(t, waves, "Job/migrate")
+	deployWave := waveOf(t, waves, "Deployment/app")
+	if !(cmWave < jobWave && jobWave < deployWave) {
+		t.Fatalf("expected ConfigMap < Job < Deployment, got %d, %d, %d", cmWave, jobWave, deployWave)
+	}
+}
@@ -37,10 +37,110 @@ var (
 		},
 		[]string{"method", "object_type"},
 	)
+
+	// goroutinePanicsTotal counts panics recovered from crash-safe background
+	// goroutines (health server, HTTP server, leader-elected startup), by name.
+	goroutinePanicsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "goroutine_panics_total",
+			Help:      "total panics recovered from named background goroutines",
+		},
+		[]string{"goroutine"},
+	)
+
+	// podReapDeletionsTotal counts pods the PodReaper successfully deleted
+	// for having outlived their AENV_TTL label, by namespace.
+	podReapDeletionsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "pod_reap_deletions_total",
+			Help:      "total pods deleted by the TTL reaper, by namespace",
+		},
+		[]string{"namespace"},
+	)
+
+	// podReapFailuresTotal counts PodReaper delete attempts that failed, by namespace.
+	podReapFailuresTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "pod_reap_failures_total",
+			Help:      "total pod deletions the TTL reaper failed to perform, by namespace",
+		},
+		[]string{"namespace"},
+	)
+
+	// parallelQueueDepth tracks jobs waiting in a pkg/parallel.Pool's queue.
+	parallelQueueDepth = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "parallel_queue_depth",
+			Help:      "jobs currently queued in the parallel worker pool",
+		},
+	)
+
+	// parallelInFlight tracks jobs a pkg/parallel.Pool's workers are currently running.
+	parallelInFlight = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "parallel_in_flight",
+			Help:      "jobs currently executing in the parallel worker pool",
+		},
+	)
+
+	// prewarmReconcileLatency records how long one env's prewarm pool
+	// reconciliation took, by env name.
+	prewarmReconcileLatency = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "prewarm_reconcile_latency_ms",
+			Help:      "latency of one prewarm pool reconciliation pass, by env",
+			Buckets:   []float64{5, 10, 30, 60, 100, 300, 600, 1000, 3000, 6000, 10000, 30000},
+		},
+		[]string{"env"},
+	)
 )
 
 func init() {
-	metrics.Registry.MustRegister(k8sApiCallLatency)
+	metrics.Registry.MustRegister(
+		k8sApiCallLatency,
+		goroutinePanicsTotal,
+		podReapDeletionsTotal,
+		podReapFailuresTotal,
+		parallelQueueDepth,
+		parallelInFlight,
+		prewarmReconcileLatency,
+	)
+}
+
+// RecordGoroutinePanic increments the panic counter for a named background goroutine.
+func RecordGoroutinePanic(name string) {
+	goroutinePanicsTotal.WithLabelValues(name).Inc()
+}
+
+// RecordPodReapDeletion increments the reaper's success counter for namespace.
+func RecordPodReapDeletion(namespace string) {
+	podReapDeletionsTotal.WithLabelValues(namespace).Inc()
+}
+
+// RecordPodReapFailure increments the reaper's failure counter for namespace.
+func RecordPodReapFailure(namespace string) {
+	podReapFailuresTotal.WithLabelValues(namespace).Inc()
+}
+
+// AddParallelQueueDepth adjusts the parallel pool's queue-depth gauge by delta.
+func AddParallelQueueDepth(delta float64) {
+	parallelQueueDepth.Add(delta)
+}
+
+// AddParallelInFlight adjusts the parallel pool's in-flight gauge by delta.
+func AddParallelInFlight(delta float64) {
+	parallelInFlight.Add(delta)
+}
+
+// RecordPrewarmReconcileLatency records how long reconciling env's prewarm pool took.
+func RecordPrewarmReconcileLatency(env string, startTime time.Time) {
+	prewarmReconcileLatency.WithLabelValues(env).Observe(float64(time.Since(startTime).Nanoseconds() / time.Millisecond.Nanoseconds()))
 }
 
 // RecordK8sApiCallLatency records latency
@@ -90,19 +90,55 @@ type Artifact struct {
 	Content string `json:"content"`
 }
 
+// SchedulingPolicy controls which member cluster an AEnvHubEnv is placed on.
+type SchedulingPolicy struct {
+	// ClusterSelector restricts placement to clusters carrying these labels.
+	ClusterSelector map[string]string `json:"clusterSelector,omitempty"`
+	// ClusterWeights biases placement among matching clusters by name.
+	ClusterWeights map[string]int32 `json:"clusterWeights,omitempty"`
+	// SpreadConstraints names dimensions (e.g. "cluster", "zone") instances
+	// of this env should be spread across when multiple clusters match.
+	SpreadConstraints []string `json:"spreadConstraints,omitempty"`
+}
+
+// WaitForCondition names the readiness predicate a ResourceSpec must satisfy
+// before an installer moves on to the next rollout wave.
+type WaitForCondition string
+
+const (
+	WaitForReady       WaitForCondition = "Ready"
+	WaitForAvailable   WaitForCondition = "Available"
+	WaitForJobComplete WaitForCondition = "JobComplete"
+)
+
+// ResourceSpec is one raw manifest in an AEnvHubEnv's ordered, multi-resource
+// rollout (e.g. a ConfigMap that must exist before the Deployment that mounts
+// it). DependsOn names other resources by "kind/name"; when empty, ordering
+// falls back to the installer's kind-priority defaults.
+type ResourceSpec struct {
+	// Manifest is the raw unstructured Kubernetes object (apiVersion/kind/metadata/...).
+	Manifest map[string]interface{} `json:"manifest"`
+	// DependsOn lists "kind/name" references that must be installed and ready first.
+	DependsOn []string `json:"dependsOn,omitempty"`
+	// WaitFor is the readiness predicate to satisfy before moving to the next wave.
+	WaitFor WaitForCondition `json:"waitFor,omitempty"`
+}
+
 // AEnvHubEnv environment information
 type AEnvHubEnv struct {
-	ID           string                 `json:"id"`           // Identifier ID
-	Name         string                 `json:"name"`         // Environment name
-	Description  string                 `json:"description"`  // Environment description
-	Version      string                 `json:"version"`      // Version
-	Tags         []string               `json:"tags"`         // Tags
-	CodeURL      string                 `json:"code_url"`     // Code URL
-	Status       EnvStatus              `json:"status"`       // Status
-	Artifacts    []Artifact             `json:"artifacts"`    // Artifact information list
-	BuildConfig  map[string]interface{} `json:"build_config"` // Build configuration
-	TestConfig   map[string]interface{} `json:"test_config"`  // Test configuration
-	DeployConfig map[string]interface{} `json:"deployConfig"` // Deployment configuration
+	ID           string                 `json:"id"`                   // Identifier ID
+	Name         string                 `json:"name"`                 // Environment name
+	Description  string                 `json:"description"`          // Environment description
+	Version      string                 `json:"version"`              // Version
+	Tags         []string               `json:"tags"`                 // Tags
+	CodeURL      string                 `json:"code_url"`             // Code URL
+	Status       EnvStatus              `json:"status"`               // Status
+	Artifacts    []Artifact             `json:"artifacts"`            // Artifact information list
+	BuildConfig  map[string]interface{} `json:"build_config"`         // Build configuration
+	TestConfig   map[string]interface{} `json:"test_config"`          // Test configuration
+	DeployConfig map[string]interface{} `json:"deployConfig"`         // Deployment configuration
+	Scheduling   SchedulingPolicy       `json:"scheduling,omitempty"` // Multi-cluster placement policy
+	Resources    []ResourceSpec         `json:"resources,omitempty"`  // Ordered multi-resource rollout
 	CreatedAt    time.Time              `json:"created_at,omitempty"`
 	UpdatedAt    time.Time              `json:"updated_at,omitempty"`
 }
@@ -82,6 +82,11 @@ type EnvCreateRequest struct {
 	ClusterName string `json:"clusterName,omitempty"`
 	// Namespace where EnvInstance is created
 	NamespaceName string `json:"namespaceName,omitempty"`
+	// PodOverlay strategic-merge-patches arbitrary PodSpec fields (nodeSelector,
+	// tolerations, volumes, securityContext, imagePullSecrets, per-container
+	// resources) onto the rendered pod template; see
+	// aenvhub_http_server.EnvOverlay for the patch semantics.
+	PodOverlay interface{} `json:"podOverlay,omitempty"`
 }
 
 type EnvContent struct {
@@ -91,6 +96,10 @@ type EnvContent struct {
 	OssUrl string `json:"ossUrl,omitempty"`
 	// Image address
 	Image string `json:"image,omitempty"`
+	// RawManifest is the original Kubernetes YAML this Env was translated
+	// from, e.g. one Pod document out of a POST /envs/play bundle. Empty for
+	// Envs created from a plain EnvCreateRequest.
+	RawManifest string `json:"rawManifest,omitempty"`
 }
 
 type EnvInstance struct {
@@ -108,6 +117,8 @@ type EnvInstance struct {
 	CreatedAt        string            `json:"createdAt,omitempty"`
 	UpdatedAt        string            `json:"updatedAt,omitempty"`
 	Address          interface{}       `json:"address,omitempty"`
+	// ClusterName is the member cluster this instance was scheduled onto.
+	ClusterName string `json:"clusterName,omitempty"`
 }
 
 func ConvertEnvInstanceToPodInfo(envInstance *EnvInstance) interface{} {
@@ -0,0 +1,129 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package parallel provides a bounded worker pool for fanning out
+// independent Kubernetes operations (pod create/delete, reconcile passes),
+// the same role Podman's pkg/parallel/ctr plays for concurrent container
+// operations.
+package parallel
+
+import (
+	"fmt"
+	"sync"
+
+	"controller/pkg/metrics"
+)
+
+// job is one unit of work submitted to a Pool, along with every waiter that
+// asked for the same coalesced key while it was in flight.
+type job struct {
+	key     string
+	fn      func() error
+	waiters []chan error
+}
+
+// Pool is a bounded pool of workers draining a single job queue. Submissions
+// for a key already in flight are coalesced onto the in-flight job instead
+// of running twice.
+type Pool struct {
+	workers int
+	jobCh   chan *job
+
+	mu       sync.Mutex
+	inflight map[string]*job
+
+	wg sync.WaitGroup
+}
+
+// NewPool creates a pool with the given number of workers (clamped to at
+// least 1) sized by e.g. a --max-parallel-ops flag.
+func NewPool(workers int) *Pool {
+	if workers <= 0 {
+		workers = 1
+	}
+	return &Pool{
+		workers:  workers,
+		jobCh:    make(chan *job, workers*4),
+		inflight: make(map[string]*job),
+	}
+}
+
+// Start launches the pool's workers; they run until stopCh is closed.
+func (p *Pool) Start(stopCh <-chan struct{}) {
+	for i := 0; i < p.workers; i++ {
+		p.wg.Add(1)
+		go p.runWorker(stopCh)
+	}
+}
+
+// Wait blocks until every worker goroutine started by Start has returned.
+func (p *Pool) Wait() {
+	p.wg.Wait()
+}
+
+func (p *Pool) runWorker(stopCh <-chan struct{}) {
+	defer p.wg.Done()
+	for {
+		select {
+		case j := <-p.jobCh:
+			metrics.AddParallelQueueDepth(-1)
+			p.run(j)
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+func (p *Pool) run(j *job) {
+	metrics.AddParallelInFlight(1)
+	err := j.fn()
+	metrics.AddParallelInFlight(-1)
+
+	p.mu.Lock()
+	delete(p.inflight, j.key)
+	p.mu.Unlock()
+
+	for _, w := range j.waiters {
+		w <- err
+		close(w)
+	}
+}
+
+// Enqueue submits fn under key and returns a channel that receives fn's
+// result exactly once. T namespaces the coalescing key, so two different
+// kinds of work (e.g. a create job and a delete job) enqueued under the same
+// key don't collide; Enqueue calls that share both T and key while the first
+// is still running are coalesced onto that single in-flight call instead of
+// running fn again.
+func Enqueue[T any](p *Pool, key string, fn func() error) <-chan error {
+	var zero T
+	coalesceKey := fmt.Sprintf("%T:%s", zero, key)
+	waiter := make(chan error, 1)
+
+	p.mu.Lock()
+	if existing, ok := p.inflight[coalesceKey]; ok {
+		existing.waiters = append(existing.waiters, waiter)
+		p.mu.Unlock()
+		return waiter
+	}
+	j := &job{key: coalesceKey, fn: fn, waiters: []chan error{waiter}}
+	p.inflight[coalesceKey] = j
+	p.mu.Unlock()
+
+	metrics.AddParallelQueueDepth(1)
+	p.jobCh <- j
+	return waiter
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package parallel
+
+import (
+	"golang.org/x/time/rate"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// EnvQueue rate-limits reconcile requests per env name, so a hot env that
+// keeps failing (or keeps changing) backs off instead of resubmitting a job
+// to the Pool on every tick.
+type EnvQueue struct {
+	queue workqueue.RateLimitingInterface
+}
+
+// NewEnvQueue creates an EnvQueue using client-go's default exponential
+// controller rate limiter (the same backoff shape controller-runtime
+// reconcilers use).
+func NewEnvQueue() *EnvQueue {
+	return &EnvQueue{
+		queue: workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+	}
+}
+
+// Add schedules env for reconciliation, respecting any backoff accumulated
+// by prior Failed calls for the same env.
+func (q *EnvQueue) Add(env string) {
+	q.queue.AddRateLimited(env)
+}
+
+// Get blocks until an env is ready to be reconciled, returning shutdown=true
+// once the queue has been shut down.
+func (q *EnvQueue) Get() (env string, shutdown bool) {
+	item, shutdown := q.queue.Get()
+	if shutdown {
+		return "", true
+	}
+	env, _ = item.(string)
+	return env, false
+}
+
+// Done marks env as finished processing, so it can be re-Added again.
+func (q *EnvQueue) Done(env string) {
+	q.queue.Done(env)
+}
+
+// Succeeded clears env's accumulated backoff after a successful reconcile.
+func (q *EnvQueue) Succeeded(env string) {
+	q.queue.Forget(env)
+}
+
+// Failed re-adds env with its backoff bumped up a step.
+func (q *EnvQueue) Failed(env string) {
+	q.queue.AddRateLimited(env)
+}
+
+// Len reports how many envs are currently queued or being processed.
+func (q *EnvQueue) Len() int {
+	return q.queue.Len()
+}
+
+// ShutDown stops the queue; pending Get calls unblock with shutdown=true.
+func (q *EnvQueue) ShutDown() {
+	q.queue.ShutDown()
+}
+
+// NewTokenBucketRateLimiter builds a workqueue.RateLimiter that permits qps
+// sustained requeues per second, bursting up to burst, combined with
+// client-go's default per-item exponential backoff so a single hot item
+// still slows down on repeated failure even while the bucket has headroom.
+// This is the limiter RetryController uses to keep pod create/delete retries
+// from overwhelming the apiserver under burst load.
+func NewTokenBucketRateLimiter(qps, burst int) workqueue.RateLimiter {
+	return workqueue.NewMaxOfRateLimiter(
+		workqueue.DefaultItemBasedRateLimiter(),
+		&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(qps), burst)},
+	)
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	aenvv1alpha1 "controller/pkg/apis/aenv/v1alpha1"
+	"controller/pkg/model"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// CRDEnvStorage implements EnvStorage on top of the Env custom resource so
+// the aenvhub HTTP server keeps working unchanged while the cluster state of
+// record becomes the Env CRD instead of an external meta-service.
+type CRDEnvStorage struct {
+	client    client.Client
+	namespace string
+}
+
+// NewCRDEnvStorage creates an EnvStorage backed by the controller-runtime client.
+func NewCRDEnvStorage(c client.Client, namespace string) *CRDEnvStorage {
+	return &CRDEnvStorage{client: c, namespace: namespace}
+}
+
+// Get fetches a single Env by name.
+func (s *CRDEnvStorage) Get(ctx context.Context, name string) (*model.Env, error) {
+	var env aenvv1alpha1.Env
+	if err := s.client.Get(ctx, types.NamespacedName{Namespace: s.namespace, Name: name}, &env); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get env %s: %v", name, err)
+	}
+	return fromCRD(&env), nil
+}
+
+// List returns every Env matching the given label selector.
+func (s *CRDEnvStorage) List(ctx context.Context, labels map[string]string) (*model.EnvList, error) {
+	var envList aenvv1alpha1.EnvList
+	opts := []client.ListOption{client.InNamespace(s.namespace)}
+	if len(labels) > 0 {
+		opts = append(opts, client.MatchingLabels(labels))
+	}
+	if err := s.client.List(ctx, &envList, opts...); err != nil {
+		return nil, fmt.Errorf("failed to list envs: %v", err)
+	}
+
+	result := &model.EnvList{Envs: make([]*model.Env, 0, len(envList.Items))}
+	for i := range envList.Items {
+		result.Envs = append(result.Envs, fromCRD(&envList.Items[i]))
+	}
+	return result, nil
+}
+
+// Create creates a new Env resource named key.
+func (s *CRDEnvStorage) Create(ctx context.Context, key string, env model.Env) error {
+	crd := toCRD(key, s.namespace, env)
+	if err := s.client.Create(ctx, crd); err != nil {
+		return fmt.Errorf("failed to create env %s: %v", key, err)
+	}
+	return nil
+}
+
+// Update updates the Env resource named key, retrying on resourceVersion
+// conflicts is left to the caller since EnvStorage exposes a single attempt.
+func (s *CRDEnvStorage) Update(ctx context.Context, key string, env model.Env) error {
+	var existing aenvv1alpha1.Env
+	if err := s.client.Get(ctx, types.NamespacedName{Namespace: s.namespace, Name: key}, &existing); err != nil {
+		return fmt.Errorf("failed to get env %s for update: %v", key, err)
+	}
+	updated := toCRD(key, s.namespace, env)
+	updated.ResourceVersion = existing.ResourceVersion
+	if err := s.client.Update(ctx, updated); err != nil {
+		return fmt.Errorf("failed to update env %s: %v", key, err)
+	}
+	return nil
+}
+
+// Delete deletes the Env resource named key.
+func (s *CRDEnvStorage) Delete(ctx context.Context, key string) error {
+	crd := &aenvv1alpha1.Env{}
+	crd.Namespace = s.namespace
+	crd.Name = key
+	if err := s.client.Delete(ctx, crd); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete env %s: %v", key, err)
+	}
+	return nil
+}
+
+func fromCRD(env *aenvv1alpha1.Env) *model.Env {
+	return &model.Env{
+		Id:          string(env.UID),
+		Name:        env.Name,
+		Description: env.Spec.Description,
+		Content: model.EnvContent{
+			ZipFile:     env.Spec.Content.ZipFile,
+			OssUrl:      env.Spec.Content.OssURL,
+			Image:       env.Spec.Content.Image,
+			RawManifest: env.Spec.Content.RawManifest,
+		},
+		Stateful:         env.Spec.Stateful,
+		Labels:           env.Spec.Labels,
+		Envs:             env.Spec.Envs,
+		Memory:           env.Spec.Memory,
+		EphemeralStorage: env.Spec.EphemeralStorage,
+		ExpiredTime:      env.Spec.ExpiredTime,
+		PrewarmSize:      env.Spec.PrewarmSize,
+		ClusterName:      env.Spec.ClusterName,
+		Namespace:        env.Spec.NamespaceName,
+		Status:           env.Status.Phase,
+	}
+}
+
+func toCRD(key, namespace string, env model.Env) *aenvv1alpha1.Env {
+	crd := &aenvv1alpha1.Env{}
+	crd.Name = key
+	crd.Namespace = namespace
+	crd.Spec = aenvv1alpha1.EnvSpec{
+		Description: env.Description,
+		Content: aenvv1alpha1.EnvContent{
+			ZipFile:     env.Content.ZipFile,
+			OssURL:      env.Content.OssUrl,
+			Image:       env.Content.Image,
+			RawManifest: env.Content.RawManifest,
+		},
+		Stateful:         env.Stateful,
+		Labels:           env.Labels,
+		Envs:             env.Envs,
+		Memory:           env.Memory,
+		EphemeralStorage: env.EphemeralStorage,
+		ExpiredTime:      env.ExpiredTime,
+		PrewarmSize:      env.PrewarmSize,
+		ClusterName:      env.ClusterName,
+		NamespaceName:    env.Namespace,
+	}
+	return crd
+}